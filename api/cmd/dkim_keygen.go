@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"ivpn.net/email/api/internal/utils"
+)
+
+// runDKIMKeygen writes a new DKIM private key and prints the TXT record to
+// publish. To rotate, publish the record, then list the key first for its
+// domain in DKIM_KEYS, keeping the previous key until its signatures expire.
+func runDKIMKeygen(args []string) error {
+	fs := flag.NewFlagSet("dkim-keygen", flag.ContinueOnError)
+	algorithm := fs.String("algorithm", "rsa", "Key algorithm (rsa or ed25519)")
+	domain := fs.String("domain", "", "Signing domain (e.g. example.net)")
+	selector := fs.String("selector", "", "DKIM selector (e.g. mx2)")
+	out := fs.String("out", "", "Private key output file")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *domain == "" || *selector == "" || *out == "" {
+		fs.Usage()
+		return fmt.Errorf("--domain, --selector and --out are required")
+	}
+
+	data, err := utils.GenerateDKIMKey(*algorithm)
+	if err != nil {
+		return err
+	}
+
+	signer, err := utils.ParseDKIMKey(data)
+	if err != nil {
+		return err
+	}
+
+	key := utils.DKIMKey{Domain: *domain, Selector: *selector, Signer: signer}
+	txt, err := key.TXTRecord()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out, data, 0600); err != nil {
+		return fmt.Errorf("writing private key: %w", err)
+	}
+
+	fmt.Printf("%s TXT \"%s\"\n", key.Name(), txt)
+	fmt.Printf("DKIM_KEYS entry: %s:%s:%s\n", *domain, *selector, *out)
+	return nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -27,9 +28,10 @@ func Run() error {
 		return err
 	}
 
-	cron.New(db.Client)
+	cron.New(db.Client, redis.Client)
 
 	service := service.New(cfg, db, redis)
+	service.StartDeliveryWorkers(context.Background())
 
 	err = api.Start(cfg.API, service, redis)
 	if err != nil {
@@ -48,6 +50,14 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "dkim-keygen" {
+		if err := runDKIMKeygen(os.Args[2:]); err != nil {
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if len(os.Args) > 1 && os.Args[1] == "send-template-managed" {
 		if err := runSendTemplateManaged(os.Args[2:]); err != nil {
 			log.Println(err)
@@ -14,15 +14,22 @@ import (
 )
 
 func smtpConfigFromEnv() config.SMTPClientConfig {
+	dkimKeys, err := config.ParseDKIMKeys(os.Getenv("DKIM_KEYS"))
+	if err != nil {
+		log.Println("Invalid DKIM_KEYS, messages will not be signed:", err)
+	}
+
 	return config.SMTPClientConfig{
-		Host:        os.Getenv("SMTP_CLIENT_HOST"),
-		Port:        os.Getenv("SMTP_CLIENT_PORT"),
-		User:        os.Getenv("SMTP_CLIENT_USER"),
-		Password:    os.Getenv("SMTP_CLIENT_PASSWORD"),
-		Sender:      os.Getenv("SMTP_CLIENT_SENDER"),
-		SenderName:  os.Getenv("SMTP_CLIENT_SENDER_NAME"),
-		Report:      os.Getenv("SMTP_CLIENT_REPORT"),
-		TokenSecret: os.Getenv("TOKEN_SECRET"),
+		Host:         os.Getenv("SMTP_CLIENT_HOST"),
+		Port:         os.Getenv("SMTP_CLIENT_PORT"),
+		User:         os.Getenv("SMTP_CLIENT_USER"),
+		Password:     os.Getenv("SMTP_CLIENT_PASSWORD"),
+		Sender:       os.Getenv("SMTP_CLIENT_SENDER"),
+		SenderName:   os.Getenv("SMTP_CLIENT_SENDER_NAME"),
+		DkimSelector: os.Getenv("SMTP_CLIENT_DKIM_SELECTOR"),
+		Report:       os.Getenv("SMTP_CLIENT_REPORT"),
+		TokenSecret:  os.Getenv("TOKEN_SECRET"),
+		DKIMKeys:     dkimKeys,
 	}
 }
 
@@ -72,6 +79,7 @@ func runSendTemplateManaged(args []string) error {
 	log.Printf("found %d managed user(s)", len(emails))
 
 	m := mailer.New(smtpConfigFromEnv())
+	defer m.Close()
 
 	var failed int
 	for _, email := range emails {
@@ -120,6 +128,7 @@ func runSendTemplate(args []string) error {
 
 	cfg := smtpConfigFromEnv()
 	m := mailer.New(cfg)
+	defer m.Close()
 
 	var failed int
 	for _, email := range recipients {
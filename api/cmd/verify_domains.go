@@ -11,6 +11,11 @@ import (
 )
 
 func runVerifyDomains() error {
+	dkimKeys, err := config.ParseDKIMKeys(os.Getenv("DKIM_KEYS"))
+	if err != nil {
+		return err
+	}
+
 	cfg := config.Config{
 		API: config.APIConfig{
 			TokenSecret: os.Getenv("TOKEN_SECRET"),
@@ -19,6 +24,7 @@ func runVerifyDomains() error {
 		SMTPClient: config.SMTPClientConfig{
 			Host:         os.Getenv("SMTP_CLIENT_HOST"),
 			DkimSelector: os.Getenv("SMTP_CLIENT_DKIM_SELECTOR"),
+			DKIMKeys:     dkimKeys,
 		},
 		DB: dbConfigFromEnv(),
 	}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -8,27 +9,28 @@ import (
 )
 
 type APIConfig struct {
-	FQDN               string
-	Name               string
-	Port               string
-	ApiAllowOrigin     string
-	ApiTrustedProxies  []string
-	ApiAllowIPs        []string
-	ApiBodyLimit       int
-	TokenSecret        string
-	TokenExpiration    time.Duration
-	ApiTokenExpiration time.Duration
-	PSK                string
-	Domains            string
-	LogFile            string
-	BasicAuthUser      string
-	BasicAuthPassword  string
-	SignupWebhookURL   string
-	SignupWebhookPSK   string
-	PreauthURL         string
-	PreauthPSK         string
-	PreauthTTL         time.Duration
-	AnnouncementsURL   string
+	FQDN                string
+	Name                string
+	Port                string
+	ApiAllowOrigin      string
+	ApiTrustedProxies   []string
+	ApiAllowIPs         []string
+	ApiBodyLimit        int
+	TokenSecret         string
+	TokenExpiration     time.Duration
+	ApiTokenExpiration  time.Duration
+	PSK                 string
+	Domains             string
+	LogFile             string
+	BasicAuthUser       string
+	BasicAuthPassword   string
+	SignupWebhookURL    string
+	SignupWebhookPSK    string
+	PreauthURL          string
+	PreauthPSK          string
+	PreauthTTL          time.Duration
+	AnnouncementsURL    string
+	AccessKeyUnusedDays int
 }
 
 type DBConfig struct {
@@ -64,6 +66,21 @@ type SMTPClientConfig struct {
 	DkimSelector string
 	Report       string
 	TokenSecret  string
+	SRSSecret    string
+	SRSDomain    string
+	ARCDomain    string
+	ARCSelector  string
+	ARCKeyFile   string
+	DKIMKeys     []DKIMKeyConfig
+	DKIMSecret   string
+}
+
+// DKIMKeyConfig is a DKIM private key file of a domain. The first key of a
+// domain signs, the others are kept published during rotation.
+type DKIMKeyConfig struct {
+	Domain   string
+	Selector string
+	File     string
 }
 
 type ServiceConfig struct {
@@ -75,6 +92,24 @@ type ServiceConfig struct {
 	MaxSessions         int
 	IdLimiterMax        int
 	IdLimiterExpiration time.Duration
+	QueueWorkers        int
+	QueueMaxAttempts    int
+	QueueBackoffBase    time.Duration
+	QueueBackoffMax     time.Duration
+	QueuePollInterval   time.Duration
+	QueueLease          time.Duration
+	DedupeTTL           time.Duration
+	InboundAliasLimit   int
+	InboundUserLimit    int
+	InboundLimitWindow  time.Duration
+	InboundLimitAction  string
+	InboundThrottle     time.Duration
+	AliasExpiredAction  string
+	QuarantineSecret    string
+	QuarantineDays      int
+	QuarantineMaxCount  int
+	QuarantineMaxSize   int
+	AuditDays           int
 }
 
 type Config struct {
@@ -152,6 +187,155 @@ func New() (Config, error) {
 		}
 	}
 
+	queueWorkers := 4
+	if v := os.Getenv("QUEUE_WORKERS"); v != "" {
+		queueWorkers, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	queueMaxAttempts := 10
+	if v := os.Getenv("QUEUE_MAX_ATTEMPTS"); v != "" {
+		queueMaxAttempts, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	queueBackoffBase := time.Minute
+	if v := os.Getenv("QUEUE_BACKOFF_BASE"); v != "" {
+		queueBackoffBase, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	queueBackoffMax := 4 * time.Hour
+	if v := os.Getenv("QUEUE_BACKOFF_MAX"); v != "" {
+		queueBackoffMax, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	queuePollInterval := 5 * time.Second
+	if v := os.Getenv("QUEUE_POLL_INTERVAL"); v != "" {
+		queuePollInterval, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	queueLease := 5 * time.Minute
+	if v := os.Getenv("QUEUE_LEASE"); v != "" {
+		queueLease, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	dedupeTTL := 24 * time.Hour
+	if v := os.Getenv("DEDUPE_TTL"); v != "" {
+		dedupeTTL, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	inboundAliasLimit := 0
+	if v := os.Getenv("INBOUND_ALIAS_LIMIT"); v != "" {
+		inboundAliasLimit, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	inboundUserLimit := 0
+	if v := os.Getenv("INBOUND_USER_LIMIT"); v != "" {
+		inboundUserLimit, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	inboundLimitWindow := time.Minute
+	if v := os.Getenv("INBOUND_LIMIT_WINDOW"); v != "" {
+		inboundLimitWindow, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	inboundLimitAction := "throttle"
+	if v := os.Getenv("INBOUND_LIMIT_ACTION"); v != "" {
+		if v != "throttle" && v != "pause" {
+			return Config{}, fmt.Errorf("invalid INBOUND_LIMIT_ACTION %q, expected throttle or pause", v)
+		}
+		inboundLimitAction = v
+	}
+
+	inboundThrottle := time.Hour
+	if v := os.Getenv("INBOUND_THROTTLE"); v != "" {
+		inboundThrottle, err = time.ParseDuration(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	aliasExpiredAction := "disable"
+	if v := os.Getenv("ALIAS_EXPIRED_ACTION"); v != "" {
+		if v != "disable" && v != "delete" {
+			return Config{}, fmt.Errorf("invalid ALIAS_EXPIRED_ACTION %q, expected disable or delete", v)
+		}
+		aliasExpiredAction = v
+	}
+
+	quarantineDays := 14
+	if v := os.Getenv("QUARANTINE_DAYS"); v != "" {
+		quarantineDays, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	quarantineMaxCount := 500
+	if v := os.Getenv("QUARANTINE_MAX_COUNT"); v != "" {
+		quarantineMaxCount, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	quarantineMaxSizeMB := 100
+	if v := os.Getenv("QUARANTINE_MAX_SIZE_MB"); v != "" {
+		quarantineMaxSizeMB, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	auditDays := 365
+	if v := os.Getenv("AUDIT_DAYS"); v != "" {
+		auditDays, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	accessKeyUnusedDays := 0
+	if v := os.Getenv("ACCESS_KEY_UNUSED_DAYS"); v != "" {
+		accessKeyUnusedDays, err = strconv.Atoi(v)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	dkimKeys, err := ParseDKIMKeys(os.Getenv("DKIM_KEYS"))
+	if err != nil {
+		return Config{}, err
+	}
+
 	preauthTTLStr := os.Getenv("PREAUTH_TTL")
 	preauthTTL, err := time.ParseDuration(preauthTTLStr)
 	if err != nil {
@@ -160,27 +344,28 @@ func New() (Config, error) {
 
 	return Config{
 		API: APIConfig{
-			FQDN:               os.Getenv("FQDN"),
-			Name:               os.Getenv("API_NAME"),
-			Port:               os.Getenv("API_PORT"),
-			ApiAllowOrigin:     os.Getenv("API_ALLOW_ORIGIN"),
-			ApiTrustedProxies:  apiTrustedProxies,
-			ApiAllowIPs:        apiAllowIPs,
-			ApiBodyLimit:       apiBodyLimitMB * 1024 * 1024,
-			TokenSecret:        os.Getenv("TOKEN_SECRET"),
-			TokenExpiration:    tokenExp,
-			ApiTokenExpiration: apiTokenExp,
-			PSK:                os.Getenv("PSK"),
-			Domains:            os.Getenv("DOMAINS"),
-			LogFile:            os.Getenv("LOG_FILE"),
-			BasicAuthUser:      os.Getenv("BASIC_AUTH_USER"),
-			BasicAuthPassword:  os.Getenv("BASIC_AUTH_PASSWORD"),
-			SignupWebhookURL:   os.Getenv("SIGNUP_WEBHOOK_URL"),
-			SignupWebhookPSK:   os.Getenv("SIGNUP_WEBHOOK_PSK"),
-			PreauthURL:         os.Getenv("PREAUTH_URL"),
-			PreauthPSK:         os.Getenv("PREAUTH_PSK"),
-			PreauthTTL:         preauthTTL,
-			AnnouncementsURL:   os.Getenv("ANNOUNCEMENTS_URL"),
+			FQDN:                os.Getenv("FQDN"),
+			Name:                os.Getenv("API_NAME"),
+			Port:                os.Getenv("API_PORT"),
+			ApiAllowOrigin:      os.Getenv("API_ALLOW_ORIGIN"),
+			ApiTrustedProxies:   apiTrustedProxies,
+			ApiAllowIPs:         apiAllowIPs,
+			ApiBodyLimit:        apiBodyLimitMB * 1024 * 1024,
+			TokenSecret:         os.Getenv("TOKEN_SECRET"),
+			TokenExpiration:     tokenExp,
+			ApiTokenExpiration:  apiTokenExp,
+			PSK:                 os.Getenv("PSK"),
+			Domains:             os.Getenv("DOMAINS"),
+			LogFile:             os.Getenv("LOG_FILE"),
+			BasicAuthUser:       os.Getenv("BASIC_AUTH_USER"),
+			BasicAuthPassword:   os.Getenv("BASIC_AUTH_PASSWORD"),
+			SignupWebhookURL:    os.Getenv("SIGNUP_WEBHOOK_URL"),
+			SignupWebhookPSK:    os.Getenv("SIGNUP_WEBHOOK_PSK"),
+			PreauthURL:          os.Getenv("PREAUTH_URL"),
+			PreauthPSK:          os.Getenv("PREAUTH_PSK"),
+			PreauthTTL:          preauthTTL,
+			AnnouncementsURL:    os.Getenv("ANNOUNCEMENTS_URL"),
+			AccessKeyUnusedDays: accessKeyUnusedDays,
 		},
 		DB: DBConfig{
 			Hosts:    dbHosts,
@@ -213,6 +398,13 @@ func New() (Config, error) {
 			DkimSelector: os.Getenv("SMTP_CLIENT_DKIM_SELECTOR"),
 			Report:       os.Getenv("SMTP_CLIENT_REPORT"),
 			TokenSecret:  os.Getenv("TOKEN_SECRET"),
+			SRSSecret:    os.Getenv("SRS_SECRET"),
+			SRSDomain:    os.Getenv("SRS_DOMAIN"),
+			ARCDomain:    os.Getenv("ARC_DOMAIN"),
+			ARCSelector:  os.Getenv("ARC_SELECTOR"),
+			ARCKeyFile:   os.Getenv("ARC_KEY_FILE"),
+			DKIMKeys:     dkimKeys,
+			DKIMSecret:   os.Getenv("DKIM_SECRET"),
 		},
 
 		Service: ServiceConfig{
@@ -224,6 +416,48 @@ func New() (Config, error) {
 			MaxSessions:         maxSessions,
 			IdLimiterMax:        idLimiterMax,
 			IdLimiterExpiration: idLimiterExpiration,
+			QueueWorkers:        queueWorkers,
+			QueueMaxAttempts:    queueMaxAttempts,
+			QueueBackoffBase:    queueBackoffBase,
+			QueueBackoffMax:     queueBackoffMax,
+			QueuePollInterval:   queuePollInterval,
+			QueueLease:          queueLease,
+			DedupeTTL:           dedupeTTL,
+			InboundAliasLimit:   inboundAliasLimit,
+			InboundUserLimit:    inboundUserLimit,
+			InboundLimitWindow:  inboundLimitWindow,
+			InboundLimitAction:  inboundLimitAction,
+			InboundThrottle:     inboundThrottle,
+			AliasExpiredAction:  aliasExpiredAction,
+			QuarantineSecret:    os.Getenv("QUARANTINE_SECRET"),
+			QuarantineDays:      quarantineDays,
+			QuarantineMaxCount:  quarantineMaxCount,
+			QuarantineMaxSize:   quarantineMaxSizeMB * 1024 * 1024,
+			AuditDays:           auditDays,
 		},
 	}, nil
 }
+
+// ParseDKIMKeys parses a comma separated list of domain:selector:file entries.
+func ParseDKIMKeys(value string) ([]DKIMKeyConfig, error) {
+	keys := []DKIMKeyConfig{}
+	for entry := range strings.SplitSeq(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid DKIM_KEYS entry %q, expected domain:selector:file", entry)
+		}
+
+		keys = append(keys, DKIMKeyConfig{
+			Domain:   strings.ToLower(parts[0]),
+			Selector: parts[1],
+			File:     parts[2],
+		})
+	}
+
+	return keys, nil
+}
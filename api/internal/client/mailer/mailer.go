@@ -9,6 +9,8 @@ import (
 	"io"
 	"log"
 	"net/mail"
+	"net/textproto"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,56 +25,103 @@ import (
 //go:embed templates/*
 var templateFS embed.FS
 
+// Mailer is safe for concurrent use and is meant to be long-lived, so SMTP
+// connections are reused across messages.
 type Mailer struct {
-	dialer *gomail.Dialer
-	cfg    config.SMTPClientConfig
+	pool     *Pool
+	cfg      config.SMTPClientConfig
+	srs      utils.SRS
+	arc      *utils.DKIMKey
+	dkim     []utils.DKIMKey
+	keyring  Keyring
+	contacts Contacts
+}
+
+// Keyring looks up the DKIM key generated for a custom domain.
+type Keyring interface {
+	DomainKey(domain string) (utils.DKIMKey, bool)
+}
+
+// Contacts maps correspondents of an alias to reply addresses and back.
+type Contacts interface {
+	ReplyAddress(alias model.Alias, address string) (string, error)
+	ResolveReplyAddress(address string) (string, bool)
 }
 
-// #nosec G104
 func New(cfg config.SMTPClientConfig) Mailer {
 	port, err := strconv.Atoi(cfg.Port)
 	if err != nil {
 		log.Println("Invalid SMTP port:", cfg.Port)
 		return Mailer{
-			dialer: nil,
-			cfg:    cfg,
+			pool: nil,
+			cfg:  cfg,
 		}
 	}
 
-	hosts := strings.Split(cfg.Host, ",")
+	var arc *utils.DKIMKey
+	if cfg.ARCKeyFile != "" {
+		arc, err = utils.LoadDKIMKey(cfg.ARCDomain, cfg.ARCSelector, cfg.ARCKeyFile)
+		if err != nil {
+			log.Println("Invalid ARC key, forwarded messages will not be sealed:", err)
+		}
+	}
 
-	var dialer *gomail.Dialer
-	for _, host := range hosts {
+	pool := &Pool{}
+	for host := range strings.SplitSeq(cfg.Host, ",") {
 		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+
+		var dialer *gomail.Dialer
 		if cfg.User == "" || cfg.Password == "" {
 			dialer = &gomail.Dialer{Host: host, Port: port}
 		} else {
 			dialer = gomail.NewDialer(host, port, cfg.User, cfg.Password)
 		}
 
-		conn, err := dialer.Dial()
-		if err != nil {
-			log.Printf("Failed to connect to SMTP host: %s, trying next host if available. Error: %v\n", host, err)
-			continue
-		} else {
-			conn.Close()
-			break
-		}
+		pool.hosts = append(pool.hosts, newSMTPHost(dialer))
 	}
 
-	if dialer == nil {
-		return Mailer{
-			dialer: nil,
-			cfg:    cfg,
+	dkim := []utils.DKIMKey{}
+	for _, k := range cfg.DKIMKeys {
+		key, err := utils.LoadDKIMKey(k.Domain, k.Selector, k.File)
+		if err != nil {
+			log.Printf("Invalid DKIM key %s for %s: %v", k.Selector, k.Domain, err)
+			continue
 		}
+		dkim = append(dkim, *key)
 	}
 
 	return Mailer{
-		dialer: dialer,
-		cfg:    cfg,
+		pool: pool,
+		cfg:  cfg,
+		srs:  utils.SRS{Secret: cfg.SRSSecret, Domain: cfg.SRSDomain},
+		arc:  arc,
+		dkim: dkim,
 	}
 }
 
+// SetKeyring sets the lookup of custom domain DKIM keys.
+func (mailer *Mailer) SetKeyring(keyring Keyring) {
+	mailer.keyring = keyring
+}
+
+// SetContacts sets the contacts reply addresses are looked up in.
+func (mailer *Mailer) SetContacts(contacts Contacts) {
+	mailer.contacts = contacts
+}
+
+// DKIMKeys returns the configured DKIM keys, signing keys first.
+func (mailer Mailer) DKIMKeys() []utils.DKIMKey {
+	return mailer.dkim
+}
+
+// Close closes idle SMTP connections.
+func (mailer Mailer) Close() {
+	mailer.pool.Close()
+}
+
 func (mailer Mailer) Send(to string, subject string, body string) error {
 	m := gomail.NewMessage()
 	m.SetAddressHeader("From", mailer.cfg.Sender, mailer.cfg.SenderName)
@@ -80,8 +129,9 @@ func (mailer Mailer) Send(to string, subject string, body string) error {
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body)
 	m.AddAlternative("text/html", body)
+	mailer.sign(m, mailer.cfg.Sender, nil)
 
-	err := mailer.dialer.DialAndSend(m)
+	err := mailer.pool.Send(m)
 	if err != nil {
 		return err
 	}
@@ -91,96 +141,23 @@ func (mailer Mailer) Send(to string, subject string, body string) error {
 }
 
 func (mailer Mailer) Reply(from string, name string, rcp model.Recipient, data []byte, alias model.Alias) error {
-	// Preprocess email data to decode RFC 2047 encoded headers
-	processedData, err := utils.PreprocessEmailData(data)
-	if err != nil {
-		log.Printf("Warning: failed to preprocess email data: %v", err)
-		processedData = data // Fallback to original data
-	}
-
-	parser := letters.NewEmailParser(
-		// Malformed headers can cause the parser to fail,
-		// so we skip parsing of headers that are not needed for forwarding
-		letters.WithToHeaderParser(
-			func(header mail.Header, s string) ([]*mail.Address, error) {
-				return nil, nil
-			},
-		),
-		letters.WithReplyToHeaderParser(
-			func(header mail.Header, s string) ([]*mail.Address, error) {
-				return nil, nil
-			},
-		),
-	)
-
-	reader := bytes.NewReader(processedData)
-	email, err := parser.Parse(reader)
+	m, messageID, err := mailer.replyMessage(from, name, rcp.Email, data, alias)
 	if err != nil {
 		return err
 	}
 
-	email.Text = utils.RemoveHeader(email.Text)
-	email.HTML = utils.RemoveHtmlHeader(email.HTML)
-
-	if email.HTML == "" {
-		email.HTML = model.PlainTextToHTML(email.Text)
-	}
-
-	// Decode the subject: letters may return an undecoded RFC 2047 value when
-	// it cannot look up the charset (e.g. an empty-charset encoded-word that
-	// slipped through preprocessing).  DecodeHeaderWithCharset is a no-op when
-	// the subject is already plain text.
-	decodedSubject := utils.DecodeHeaderWithCharset(email.Headers.Subject)
-
-	m := gomail.NewMessage()
-	m.SetAddressHeader("From", from, name)
-	m.SetHeader("To", rcp.Email)
-	m.SetHeader("Subject", decodedSubject)
-	m.SetBody("text/plain", email.Text)
-	m.AddAlternative("text/html", email.HTML)
-
-	// Preserve original metadata
-	if len(email.Headers.InReplyTo) > 0 {
-		m.SetHeader("In-Reply-To", string(email.Headers.InReplyTo[0]))
-	}
-	m.SetHeader("X-Complaints-To", mailer.cfg.Report)
-	m.SetHeader("X-Report-Abuse", mailer.cfg.Report)
-	m.SetHeader("X-Report-Abuse-To", mailer.cfg.Report)
-	m.SetHeader("Feedback-ID", fmt.Sprintf("mailx:%x:reply", sha256.Sum256([]byte(mailer.cfg.TokenSecret+alias.ID))))
-
-	for _, a := range email.AttachedFiles {
-		m.Attach(a.ContentDisposition.Params["filename"], gomail.SetCopyFunc(func(w io.Writer) error {
-			_, err = w.Write(a.Data)
-			return err
-		}))
-	}
-
-	for _, f := range email.InlineFiles {
-		m.Embed(
-			f.ContentID,
-			gomail.SetHeader(map[string][]string{
-				"Content-ID":          {f.ContentID},
-				"Content-Type":        {f.ContentType.ContentType},
-				"Content-Disposition": {f.ContentDisposition.Params["type"] + "; filename=\"" + f.ContentDisposition.Params["filename"] + "\""},
-			}),
-			gomail.SetCopyFunc(func(w io.Writer) error {
-				_, err = w.Write(f.Data)
-				return err
-			}),
-		)
-	}
-
-	err = mailer.dialer.DialAndSend(m)
+	mailer.sign(m, from, nil)
+	err = mailer.pool.Send(m)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("Email reply sent successfully, %s", email.Headers.MessageID)
+	log.Printf("Email reply sent successfully, %s", messageID)
 
 	return nil
 }
 
-func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data []byte, templateFile string, templateData any, settings model.Settings, alias model.Alias) error {
+func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data []byte, templateFile string, templateData any, settings model.Settings, alias model.Alias, filtered model.FilterResult) error {
 	// Preprocess email data to decode RFC 2047 encoded headers
 	processedData, err := utils.PreprocessEmailData(data)
 	if err != nil {
@@ -240,16 +217,31 @@ func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data
 	// the subject is already plain text.
 	decodedSubject := utils.DecodeHeaderWithCharset(email.Headers.Subject)
 
+	// Filters tagging or flagging the forwarded copy
+	decodedSubject = filtered.Subject(decodedSubject)
+
+	// Spam policy tagging messages flagged by rspamd
+	if model.GetSpamPolicy(alias, settings) == model.SpamTag && model.ParseSpamScore(extraHeaders(email)).Spam {
+		decodedSubject = model.TagSpamSubject(decodedSubject)
+	}
+
 	m := gomail.NewMessage()
 	m.SetAddressHeader("From", from, name)
 	m.SetHeader("To", rcp.Email)
+	cc := mailer.forwardCc(data, alias, rcp.Email)
+	if len(cc) > 0 {
+		m.SetHeader("Cc", cc...)
+	}
+	m.SetEnvelopeTo(rcp.Email)
 	m.SetHeader("Subject", decodedSubject)
 	m.SetBody("text/plain", header.String()+email.Text)
 
 	// Preserve original metadata
 	originalSender := from
+	returnPath := ""
 	if envFrom, ok := email.Headers.ExtraHeaders["Return-Path"]; ok && len(envFrom) > 0 {
 		originalSender = envFrom[0]
+		returnPath = envFrom[0]
 	}
 	m.SetHeader("X-Mailx-Original-Envelope-From", originalSender)
 	m.SetHeader("X-Mailx-Original-Sender", originalSender)
@@ -260,7 +252,8 @@ func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data
 	if email.Headers.MessageID != "" {
 		m.SetHeader("Message-ID", string(email.Headers.MessageID))
 	}
-	if authResults, ok := email.Headers.ExtraHeaders["Authentication-Results"]; ok && len(authResults) > 0 {
+	authResults := email.Headers.ExtraHeaders["Authentication-Results"]
+	if len(authResults) > 0 {
 		m.SetHeader("X-Mailx-Authentication-Results", authResults...)
 	}
 	if len(email.Headers.InReplyTo) > 0 {
@@ -278,15 +271,9 @@ func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data
 		allReceived := append([]string{ownHop}, received...)
 		m.SetHeader("Received", allReceived...)
 	}
-	arcHeaders := []string{
-		"ARC-Seal",
-		"ARC-Message-Signature",
-		"ARC-Authentication-Results",
-	}
-	for _, h := range arcHeaders {
-		if vals, ok := email.Headers.ExtraHeaders[h]; ok && len(vals) > 0 {
-			m.SetHeader(h, vals...)
-		}
+	if filtered.Spam {
+		m.SetHeader("X-Spam-Flag", "YES")
+		m.SetHeader("X-Mailx-Spam", "filter")
 	}
 	m.SetHeader("X-Complaints-To", mailer.cfg.Report)
 	m.SetHeader("X-Report-Abuse", mailer.cfg.Report)
@@ -333,8 +320,13 @@ func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data
 		if err != nil {
 			return err
 		}
+		if len(cc) > 0 {
+			em.SetHeader("Cc", cc...)
+		}
+		em.SetEnvelopeTo(rcp.Email)
+		mailer.seal(em, from, returnPath, authResults)
 
-		err = mailer.dialer.DialAndSend(em)
+		err = mailer.pool.Send(em)
 		if err != nil {
 			return err
 		}
@@ -343,7 +335,8 @@ func (mailer Mailer) Forward(from string, name string, rcp model.Recipient, data
 		return nil
 	}
 
-	err = mailer.dialer.DialAndSend(m)
+	mailer.seal(m, from, returnPath, authResults)
+	err = mailer.pool.Send(m)
 	if err != nil {
 		return err
 	}
@@ -376,8 +369,9 @@ func (mailer Mailer) SendTemplate(to string, subject string, templateFile string
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", body.String())
 	m.AddAlternative("text/html", bodyHtml.String())
+	mailer.sign(m, mailer.cfg.Sender, nil)
 
-	err = mailer.dialer.DialAndSend(m)
+	err = mailer.pool.Send(m)
 	if err != nil {
 		return err
 	}
@@ -385,3 +379,108 @@ func (mailer Mailer) SendTemplate(to string, subject string, templateFile string
 	log.Println("Email template sent successfully")
 	return nil
 }
+
+// seal rewrites the original envelope sender of a forwarded message with SRS
+// and signs it with our own ARC set, as the upstream chain breaks on rewriting.
+func (mailer Mailer) seal(m *gomail.Message, from string, sender string, authResults []string) {
+	sender = strings.Trim(strings.TrimSpace(sender), "<>")
+	if mailer.srs.Secret != "" && mailer.srs.Domain != "" && sender != "" {
+		envelopeFrom, err := mailer.srs.Forward(sender, time.Now())
+		if err != nil {
+			log.Printf("Warning: failed to rewrite envelope sender %s: %v", sender, err)
+		} else {
+			m.SetEnvelopeFrom(envelopeFrom)
+		}
+	}
+
+	var arc gomail.Signer
+	if mailer.arc != nil {
+		results := ""
+		if len(authResults) > 0 {
+			results = authResults[0]
+		}
+		key := *mailer.arc
+		arc = func(msg []byte) ([]byte, error) {
+			return utils.SealARC(msg, key, results, time.Now())
+		}
+	}
+
+	mailer.sign(m, from, arc)
+}
+
+// sign signs the message with the DKIM key of the From domain, then with arc
+// when set, so the ARC set covers the DKIM signature.
+func (mailer Mailer) sign(m *gomail.Message, from string, arc gomail.Signer) {
+	signers := []gomail.Signer{}
+	if key, ok := mailer.dkimKey(from); ok {
+		signers = append(signers, func(msg []byte) ([]byte, error) {
+			return utils.SignDKIM(msg, key, time.Now())
+		})
+	}
+	if arc != nil {
+		signers = append(signers, arc)
+	}
+
+	if len(signers) == 0 {
+		return
+	}
+
+	m.SetSigner(func(msg []byte) ([]byte, error) {
+		fields := []byte{}
+		for _, signer := range signers {
+			field, err := signer(append(fields, msg...))
+			if err != nil {
+				return nil, err
+			}
+			fields = append(field, fields...)
+		}
+
+		return fields, nil
+	})
+}
+
+// dkimKey returns the key signing mail from address. Service domains sign
+// with their first configured key, custom domains with their own generated
+// key if any. Otherwise custom domains delegate the SMTP_CLIENT_DKIM_SELECTOR
+// selectors to us with a CNAME, so they sign with a key of such a selector
+// under their own domain.
+func (mailer Mailer) dkimKey(address string) (utils.DKIMKey, bool) {
+	i := strings.LastIndex(address, "@")
+	if i < 0 {
+		return utils.DKIMKey{}, false
+	}
+	domain := strings.ToLower(address[i+1:])
+
+	for _, key := range mailer.dkim {
+		if key.Domain == domain {
+			return key, true
+		}
+	}
+
+	if mailer.keyring != nil {
+		if key, ok := mailer.keyring.DomainKey(domain); ok {
+			return key, true
+		}
+	}
+
+	selectors := strings.Split(mailer.cfg.DkimSelector, ",")
+	for _, key := range mailer.dkim {
+		if mailer.cfg.DkimSelector == "" || slices.Contains(selectors, key.Selector) {
+			key.Domain = domain
+			return key, true
+		}
+	}
+
+	return utils.DKIMKey{}, false
+}
+
+// extraHeaders returns the headers of the parsed email not mapped to fields,
+// with canonical keys.
+func extraHeaders(email letters.Email) mail.Header {
+	headers := mail.Header{}
+	for k, v := range email.Headers.ExtraHeaders {
+		headers[textproto.CanonicalMIMEHeaderKey(k)] = v
+	}
+
+	return headers
+}
@@ -1,9 +1,16 @@
 package mailer
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"io"
+	"strings"
 	"testing"
 
 	"ivpn.net/email/api/config"
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
 	"ivpn.net/email/api/internal/utils/gomail.v2"
 )
 
@@ -19,8 +26,8 @@ func TestSend(t *testing.T) {
 		{
 			name: "Send email with error",
 			mailer: Mailer{
-				dialer: &gomail.Dialer{Host: "invalid-host", Port: 587},
-				cfg:    config.SMTPClientConfig{Sender: "sender@example.com"},
+				pool: &Pool{hosts: []*smtpHost{newSMTPHost(&gomail.Dialer{Host: "invalid-host", Port: 587})}},
+				cfg:  config.SMTPClientConfig{Sender: "sender@example.com"},
 			},
 			to:      "recipient@example.com",
 			subject: "Test Subject",
@@ -47,8 +54,8 @@ func TestNew_InvalidPort(t *testing.T) {
 		Password: "",
 	}
 	mailer := New(cfg)
-	if mailer.dialer != nil {
-		t.Errorf("expected dialer to be nil, got %v", mailer.dialer)
+	if mailer.pool != nil {
+		t.Errorf("expected pool to be nil, got %v", mailer.pool)
 	}
 	if mailer.cfg.Sender != cfg.Sender {
 		t.Errorf("expected sender %s, got %s", cfg.Sender, mailer.cfg.Sender)
@@ -67,4 +74,219 @@ func TestNew_MultipleHosts_Failure(t *testing.T) {
 	if mailer.cfg.Sender != cfg.Sender {
 		t.Errorf("expected sender %s, got %s", cfg.Sender, mailer.cfg.Sender)
 	}
+	if len(mailer.pool.hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(mailer.pool.hosts))
+	}
+	if mailer.pool.hosts[0].name != "invalid-host1" || mailer.pool.hosts[1].name != "invalid-host2" {
+		t.Errorf("unexpected hosts %s, %s", mailer.pool.hosts[0].name, mailer.pool.hosts[1].name)
+	}
+}
+
+func TestSeal(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mailer := Mailer{
+		srs:  utils.SRS{Secret: "secret", Domain: "mailx.net"},
+		arc:  &utils.DKIMKey{Domain: "mailx.net", Selector: "arc", Signer: key},
+		dkim: []utils.DKIMKey{{Domain: "mailx.net", Selector: "mx1", Signer: key}},
+	}
+
+	m := testMessage()
+	mailer.seal(m, "alias@mailx.net", "<john@example.com>", []string{"mx.mailx.net; spf=pass"})
+
+	var from string
+	var raw bytes.Buffer
+	err = gomail.Send(gomail.SendFunc(func(f string, to []string, msg io.WriterTo) error {
+		from = f
+		_, err := msg.WriteTo(&raw)
+		return err
+	}), m)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if !strings.HasPrefix(from, "SRS0=") || !strings.HasSuffix(from, "=example.com=john@mailx.net") {
+		t.Errorf("unexpected envelope sender %s", from)
+	}
+	if !strings.HasPrefix(raw.String(), "ARC-Seal: i=1; a=ed25519-sha256;") {
+		t.Errorf("expected ARC set first, got %q", raw.String())
+	}
+	if !strings.Contains(raw.String(), "ARC-Authentication-Results: i=1; mx.mailx.net; spf=pass\r\n") {
+		t.Error("expected ARC-Authentication-Results")
+	}
+	if !strings.Contains(raw.String(), "\r\nDKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed; d=mailx.net; s=mx1;") {
+		t.Error("expected DKIM-Signature after the ARC set")
+	}
+}
+
+func TestDKIMKey(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		selectors string
+		from      string
+		domain    string
+		selector  string
+		ok        bool
+	}{
+		{"service domain", "", "alias@mailx.net", "mailx.net", "mx1", true},
+		{"second service domain", "", "alias@mailx.org", "mailx.org", "mx3", true},
+		{"custom domain", "", "alias@custom.com", "custom.com", "mx1", true},
+		{"custom domain delegated selector", "mx2", "alias@custom.com", "custom.com", "mx2", true},
+		{"custom domain no delegated key", "mx9", "alias@custom.com", "", "", false},
+		{"invalid address", "", "alias", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := Mailer{
+				cfg: config.SMTPClientConfig{DkimSelector: tt.selectors},
+				dkim: []utils.DKIMKey{
+					{Domain: "mailx.net", Selector: "mx1", Signer: key},
+					{Domain: "mailx.net", Selector: "mx2", Signer: key},
+					{Domain: "mailx.org", Selector: "mx3", Signer: key},
+				},
+			}
+
+			got, ok := mailer.dkimKey(tt.from)
+			if ok != tt.ok || got.Domain != tt.domain || got.Selector != tt.selector {
+				t.Errorf("dkimKey() = %s, %s, %v", got.Domain, got.Selector, ok)
+			}
+		})
+	}
+}
+
+func TestReplyMessage(t *testing.T) {
+	body := "--b1\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n\r\n" +
+		"See you there.\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/calendar; method=REQUEST; charset=UTF-8\r\n\r\n" +
+		"BEGIN:VCALENDAR\r\nMETHOD:REQUEST\r\nEND:VCALENDAR\r\n" +
+		"--b1\r\n" +
+		"Content-Type: message/rfc822\r\n\r\n" +
+		"From: Jane <jane@example.org>\r\nSubject: Agenda\r\n\r\nAgenda\r\n" +
+		"--b1--\r\n"
+	data := []byte("Received: from [10.0.0.1] by mx.provider.com\r\n" +
+		"DKIM-Signature: v=1; a=rsa-sha256; d=provider.com; s=s1; b=abc\r\n" +
+		"From: John <john@provider.com>\r\n" +
+		"Reply-To: john@provider.com\r\n" +
+		"To: alias+bob=example.com@mailx.net, Erin <alias+erin=example.com@mailx.net>\r\n" +
+		"Cc: Carol <alias+carol=example.net@mailx.net>, dave@example.com\r\n" +
+		"Subject: =?UTF-8?Q?Re:_Meeting?=\r\n" +
+		"Message-ID: <123@john-laptop.provider.com>\r\n" +
+		"In-Reply-To: <abc@example.com>\r\n" +
+		"References: <xyz@example.com>\r\n <122@mail.provider.com><abc@example.com>\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n\r\n" + body)
+
+	mailer := Mailer{cfg: config.SMTPClientConfig{TokenSecret: "secret", Report: "abuse@mailx.net"}}
+	m, messageID, err := mailer.replyMessage("alias@mailx.net", "Alias", "bob@example.com", data, model.Alias{Name: "alias@mailx.net"})
+	if err != nil {
+		t.Fatalf("replyMessage() error = %v", err)
+	}
+	if messageID != "<123@john-laptop.provider.com>" {
+		t.Errorf("replyMessage() messageID = %s", messageID)
+	}
+
+	var from string
+	var rcpts []string
+	var raw bytes.Buffer
+	err = gomail.Send(gomail.SendFunc(func(f string, to []string, msg io.WriterTo) error {
+		from = f
+		rcpts = to
+		_, err := msg.WriteTo(&raw)
+		return err
+	}), m)
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if from != "alias@mailx.net" {
+		t.Errorf("unexpected envelope sender %s", from)
+	}
+	if len(rcpts) != 1 || rcpts[0] != "bob@example.com" {
+		t.Errorf("unexpected envelope recipients %v", rcpts)
+	}
+
+	headers, out, ok := strings.Cut(raw.String(), "\r\n\r\n")
+	headers += "\r\n"
+	if !ok || out != body {
+		t.Errorf("expected body to be kept, got %q", out)
+	}
+
+	for _, want := range []string{
+		"From: \"Alias\" <alias@mailx.net>\r\n",
+		"To: <bob@example.com>, \"Erin\" <erin@example.com>\r\n",
+		"Cc: \"Carol\" <carol@example.net>\r\n",
+		"Subject: =?UTF-8?Q?Re:_Meeting?=\r\n",
+		"In-Reply-To: <abc@example.com>\r\n",
+		"References: <xyz@example.com> " + mailer.replyMessageID("<122@mail.provider.com>", "alias@mailx.net") + "\r\n <abc@example.com>\r\n",
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n",
+		"@mailx.net>\r\n",
+	} {
+		if !strings.Contains(headers, want) {
+			t.Errorf("expected %q in headers %q", want, headers)
+		}
+	}
+
+	for _, leak := range []string{"provider.com", "Received:", "\nReply-To:", "DKIM-Signature:", "dave@example.com"} {
+		if strings.Contains(headers, leak) {
+			t.Errorf("unexpected %q in headers %q", leak, headers)
+		}
+	}
+}
+
+func TestForwardCc(t *testing.T) {
+	data := []byte("From: bob@example.com\r\n" +
+		"To: alias@mailx.net, Erin <erin@example.com>\r\n" +
+		"Cc: carol@example.net, john@provider.com, alias+dave=example.com@mailx.net\r\n" +
+		"Subject: Meeting\r\n\r\nHello\r\n")
+
+	got := Mailer{}.forwardCc(data, model.Alias{Name: "alias@mailx.net"}, "john@provider.com")
+	want := []string{
+		"\"Erin\" <alias+erin=example.com@mailx.net>",
+		"<alias+carol=example.net@mailx.net>",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("forwardCc() = %v, want %v", got, want)
+	}
+}
+
+type testContacts map[string]string
+
+func (c testContacts) ReplyAddress(alias model.Alias, address string) (string, error) {
+	for replyTo, a := range c {
+		if a == address {
+			return replyTo, nil
+		}
+	}
+	return "", io.EOF
+}
+
+func (c testContacts) ResolveReplyAddress(address string) (string, bool) {
+	a, ok := c[address]
+	return a, ok
+}
+
+func TestReplyAddresses(t *testing.T) {
+	mailer := Mailer{contacts: testContacts{"alias+abcdefgh234567ab@mailx.net": "bob@example.com"}}
+
+	got := mailer.replyAddresses("alias+abcdefgh234567ab@mailx.net, alias+carol=example.net@mailx.net, dave@example.com, other+erin=example.com@mailx.net", model.Alias{Name: "alias@mailx.net"})
+	want := "<bob@example.com>, <carol@example.net>"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("replyAddresses() = %v, want %v", got, want)
+	}
+
+	cc := mailer.forwardCc([]byte("To: alias@mailx.net, bob@example.com, erin@example.com\r\n\r\nHello\r\n"), model.Alias{Name: "alias@mailx.net"}, "john@provider.com")
+	if strings.Join(cc, ", ") != "<alias+abcdefgh234567ab@mailx.net>" {
+		t.Errorf("forwardCc() = %v", cc)
+	}
 }
@@ -0,0 +1,209 @@
+package mailer
+
+import (
+	"errors"
+	"log"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"ivpn.net/email/api/internal/utils/gomail.v2"
+)
+
+const (
+	poolMaxIdle        = 4                // idle connections kept per host
+	poolIdleTimeout    = 30 * time.Second // idle connections older than this are closed
+	breakerThreshold   = 3                // consecutive failures opening the circuit
+	breakerCooldown    = time.Minute      // time before an open circuit is retried
+	permanentErrorCode = 500
+)
+
+var (
+	ErrNoSMTPHost = errors.New("no SMTP host available")
+)
+
+type pooledConn struct {
+	sender   gomail.SendCloser
+	lastUsed time.Time
+}
+
+// smtpHost is a pool of connections to a single SMTP host with a circuit breaker.
+type smtpHost struct {
+	name string
+	dial func() (gomail.SendCloser, error)
+
+	mu        sync.Mutex
+	idle      []*pooledConn
+	failures  int
+	openUntil time.Time
+}
+
+func newSMTPHost(dialer *gomail.Dialer) *smtpHost {
+	return &smtpHost{
+		name: dialer.Host,
+		dial: dialer.Dial,
+	}
+}
+
+// available reports whether the circuit is closed, or open long enough to be
+// retried (half-open).
+func (h *smtpHost) available(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.failures < breakerThreshold || now.After(h.openUntil)
+}
+
+func (h *smtpHost) success() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures = 0
+	h.openUntil = time.Time{}
+}
+
+func (h *smtpHost) failure(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.failures++
+	if h.failures >= breakerThreshold {
+		if h.failures == breakerThreshold {
+			log.Printf("SMTP host %s failing, circuit open for %s", h.name, breakerCooldown)
+		}
+		h.openUntil = now.Add(breakerCooldown)
+	}
+}
+
+// get returns a healthy idle connection or dials a new one.
+func (h *smtpHost) get(now time.Time) (*pooledConn, error) {
+	for {
+		h.mu.Lock()
+		if len(h.idle) == 0 {
+			h.mu.Unlock()
+			break
+		}
+		conn := h.idle[len(h.idle)-1]
+		h.idle = h.idle[:len(h.idle)-1]
+		h.mu.Unlock()
+
+		if now.Sub(conn.lastUsed) > poolIdleTimeout || !healthy(conn.sender) {
+			conn.sender.Close() // #nosec G104
+			continue
+		}
+
+		return conn, nil
+	}
+
+	sender, err := h.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pooledConn{sender: sender, lastUsed: now}, nil
+}
+
+func (h *smtpHost) put(conn *pooledConn, now time.Time) {
+	conn.lastUsed = now
+
+	h.mu.Lock()
+	if len(h.idle) < poolMaxIdle {
+		h.idle = append(h.idle, conn)
+		h.mu.Unlock()
+		return
+	}
+	h.mu.Unlock()
+
+	conn.sender.Close() // #nosec G104
+}
+
+func (h *smtpHost) close() {
+	h.mu.Lock()
+	idle := h.idle
+	h.idle = nil
+	h.mu.Unlock()
+
+	for _, conn := range idle {
+		conn.sender.Close() // #nosec G104
+	}
+}
+
+// healthy checks an idle connection with NOOP when supported.
+func healthy(sender gomail.SendCloser) bool {
+	if n, ok := sender.(interface{ Noop() error }); ok {
+		return n.Noop() == nil
+	}
+
+	return true
+}
+
+// IsPermanent reports whether the server rejected the message itself (5xx),
+// in which case another host, or a later attempt, would reject it as well.
+func IsPermanent(err error) bool {
+	var tpErr *textproto.Error
+	if errors.As(err, &tpErr) {
+		return tpErr.Code >= permanentErrorCode
+	}
+
+	return false
+}
+
+// Pool sends messages over pooled SMTP connections, failing over across hosts
+// in the configured order and skipping hosts with an open circuit.
+type Pool struct {
+	hosts []*smtpHost
+}
+
+func (p *Pool) Send(m *gomail.Message) error {
+	if p == nil || len(p.hosts) == 0 {
+		return ErrNoSMTPHost
+	}
+
+	lastErr := ErrNoSMTPHost
+	for _, h := range p.hosts {
+		now := time.Now()
+		if !h.available(now) {
+			continue
+		}
+
+		conn, err := h.get(now)
+		if err != nil {
+			log.Printf("Failed to connect to SMTP host: %s, trying next host if available. Error: %v\n", h.name, err)
+			h.failure(now)
+			lastErr = err
+			continue
+		}
+
+		err = gomail.Send(conn.sender, m)
+		if err == nil {
+			h.success()
+			h.put(conn, time.Now())
+			return nil
+		}
+
+		// Connection state is unknown after an error, never reuse it
+		conn.sender.Close() // #nosec G104
+
+		if IsPermanent(err) {
+			h.success()
+			return err
+		}
+
+		log.Printf("Failed to send via SMTP host: %s, trying next host if available. Error: %v\n", h.name, err)
+		h.failure(now)
+		lastErr = err
+	}
+
+	return lastErr
+}
+
+// Close closes all idle connections.
+func (p *Pool) Close() {
+	if p == nil {
+		return
+	}
+
+	for _, h := range p.hosts {
+		h.close()
+	}
+}
@@ -0,0 +1,162 @@
+package mailer
+
+import (
+	"errors"
+	"io"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"ivpn.net/email/api/internal/utils/gomail.v2"
+)
+
+type fakeSender struct {
+	err    error
+	sent   int
+	closed bool
+}
+
+func (s *fakeSender) Send(from string, to []string, msg io.WriterTo) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.sent++
+	return nil
+}
+
+func (s *fakeSender) Close() error {
+	s.closed = true
+	return nil
+}
+
+type fakeHost struct {
+	sendErr error
+	dialErr error
+	dials   int
+	senders []*fakeSender
+}
+
+func (f *fakeHost) smtpHost(name string) *smtpHost {
+	return &smtpHost{
+		name: name,
+		dial: func() (gomail.SendCloser, error) {
+			f.dials++
+			if f.dialErr != nil {
+				return nil, f.dialErr
+			}
+			s := &fakeSender{err: f.sendErr}
+			f.senders = append(f.senders, s)
+			return s, nil
+		},
+	}
+}
+
+func testMessage() *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", "from@example.com")
+	m.SetHeader("To", "to@example.com")
+	m.SetBody("text/plain", "Body")
+	return m
+}
+
+func TestPoolSend_ReusesConnection(t *testing.T) {
+	host := &fakeHost{}
+	pool := &Pool{hosts: []*smtpHost{host.smtpHost("primary")}}
+
+	for range 3 {
+		if err := pool.Send(testMessage()); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if host.dials != 1 {
+		t.Errorf("expected 1 dial, got %d", host.dials)
+	}
+	if host.senders[0].sent != 3 {
+		t.Errorf("expected 3 messages on pooled connection, got %d", host.senders[0].sent)
+	}
+}
+
+func TestPoolSend_Failover(t *testing.T) {
+	primary := &fakeHost{dialErr: errors.New("connection refused")}
+	secondary := &fakeHost{}
+	pool := &Pool{hosts: []*smtpHost{primary.smtpHost("primary"), secondary.smtpHost("secondary")}}
+
+	if err := pool.Send(testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if len(secondary.senders) != 1 || secondary.senders[0].sent != 1 {
+		t.Errorf("expected message sent via secondary host")
+	}
+}
+
+func TestPoolSend_CircuitBreaker(t *testing.T) {
+	primary := &fakeHost{dialErr: errors.New("connection refused")}
+	secondary := &fakeHost{}
+	pool := &Pool{hosts: []*smtpHost{primary.smtpHost("primary"), secondary.smtpHost("secondary")}}
+
+	for range breakerThreshold + 2 {
+		if err := pool.Send(testMessage()); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	if primary.dials != breakerThreshold {
+		t.Errorf("expected %d dials before circuit opens, got %d", breakerThreshold, primary.dials)
+	}
+
+	// Half-open after cooldown
+	pool.hosts[0].openUntil = time.Now().Add(-time.Second)
+	primary.dialErr = nil
+	if err := pool.Send(testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if pool.hosts[0].failures != 0 {
+		t.Errorf("expected circuit to close after success, got %d failures", pool.hosts[0].failures)
+	}
+}
+
+func TestPoolSend_AllHostsUnavailable(t *testing.T) {
+	primary := &fakeHost{dialErr: errors.New("connection refused")}
+	pool := &Pool{hosts: []*smtpHost{primary.smtpHost("primary")}}
+
+	for range breakerThreshold {
+		if err := pool.Send(testMessage()); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+
+	err := pool.Send(testMessage())
+	if !errors.Is(err, ErrNoSMTPHost) {
+		t.Errorf("expected ErrNoSMTPHost, got %v", err)
+	}
+}
+
+func TestPoolSend_PermanentError(t *testing.T) {
+	primary := &fakeHost{sendErr: &textproto.Error{Code: 550, Msg: "mailbox unavailable"}}
+	secondary := &fakeHost{}
+	pool := &Pool{hosts: []*smtpHost{primary.smtpHost("primary"), secondary.smtpHost("secondary")}}
+
+	err := pool.Send(testMessage())
+	if !IsPermanent(err) {
+		t.Fatalf("expected permanent error, got %v", err)
+	}
+
+	if secondary.dials != 0 {
+		t.Errorf("expected no failover on permanent error")
+	}
+	if !primary.senders[0].closed {
+		t.Errorf("expected failed connection to be closed")
+	}
+	if pool.hosts[0].failures != 0 {
+		t.Errorf("expected permanent error not to count towards the circuit breaker")
+	}
+}
+
+func TestPoolSend_NilPool(t *testing.T) {
+	var pool *Pool
+	if err := pool.Send(testMessage()); !errors.Is(err, ErrNoSMTPHost) {
+		t.Errorf("expected ErrNoSMTPHost, got %v", err)
+	}
+}
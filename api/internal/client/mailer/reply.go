@@ -0,0 +1,226 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
+	"ivpn.net/email/api/internal/utils/gomail.v2"
+)
+
+// replyHeaders are the header fields of a reply or send kept as they are.
+// Other fields, such as Received, Reply-To, Sender or DKIM-Signature, would
+// reveal the recipient address or its provider and are dropped. In-Reply-To
+// and References are kept with the recipient's own Message-IDs rewritten.
+var replyHeaders = []string{
+	"Subject", "Date", "Mime-Version",
+	"Content-Type", "Content-Transfer-Encoding", "Content-Disposition",
+	"Content-Description", "Content-Language", "Thread-Topic", "Thread-Index",
+	"Importance", "Priority", "X-Priority", "Sensitivity",
+}
+
+// replyMessage rewrites the message a recipient sends through an alias to one
+// of its external recipients, to. The MIME body is kept byte for byte, so
+// attachments, calendar invites, nested messages and S/MIME signatures
+// survive. Only From, To, Cc, the envelope and the Message-IDs are rewritten.
+// Each To and Cc recipient gets a copy showing all of them, Bcc recipients are
+// in the envelope only.
+func (mailer Mailer) replyMessage(from string, name string, to string, data []byte, alias model.Alias) (*gomail.Message, string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	m := gomail.NewRawMessage(gomail.SetCharset("UTF-8"))
+	for _, key := range replyHeaders {
+		if values := msg.Header[textproto.CanonicalMIMEHeaderKey(key)]; len(values) > 0 {
+			m.SetHeader(key, values...)
+		}
+	}
+	m.SetAddressHeader("From", from, name)
+	if rcpts := mailer.replyAddresses(msg.Header.Get("To"), alias); len(rcpts) > 0 {
+		m.SetHeader("To", rcpts...)
+	} else {
+		m.SetHeader("To", to)
+	}
+	if cc := mailer.replyAddresses(msg.Header.Get("Cc"), alias); len(cc) > 0 {
+		m.SetHeader("Cc", cc...)
+	}
+
+	messageID := strings.TrimSpace(msg.Header.Get("Message-ID"))
+	if messageID != "" {
+		m.SetHeader("Message-ID", mailer.replyMessageID(messageID, from))
+	}
+
+	domains := []string{messageIDDomain(messageID)}
+	if sender, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		domains = append(domains, messageIDDomain("<"+sender.Address+">"))
+	}
+	for _, key := range []string{"In-Reply-To", "References"} {
+		if value := msg.Header.Get(key); value != "" {
+			m.SetHeader(key, mailer.replyReferences(value, domains, from))
+		}
+	}
+
+	m.SetHeader("X-Complaints-To", mailer.cfg.Report)
+	m.SetHeader("X-Report-Abuse", mailer.cfg.Report)
+	m.SetHeader("X-Report-Abuse-To", mailer.cfg.Report)
+	m.SetHeader("Feedback-ID", fmt.Sprintf("mailx:%x:reply", sha256.Sum256([]byte(mailer.cfg.TokenSecret+alias.ID))))
+	m.SetEnvelopeFrom(from)
+	m.SetEnvelopeTo(to)
+	m.SetRawBody(string(body))
+
+	return m, messageID, nil
+}
+
+// replyMessageID returns the Message-ID of a reply under the alias domain.
+// It is derived from the original one, which may name the recipient host,
+// so a retried delivery keeps the same Message-ID.
+func (mailer Mailer) replyMessageID(messageID string, from string) string {
+	domain := from
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+
+	hash := sha256.Sum256([]byte(mailer.cfg.TokenSecret + messageID))
+	return fmt.Sprintf("<%x@%s>", hash[:16], domain)
+}
+
+// replyReferences returns an In-Reply-To or References value with the
+// Message-IDs under one of the recipient's domains, or their subdomains,
+// replaced by replyMessageID. Those are the Message-IDs of earlier replies,
+// which the correspondent only knows by the rewritten one, and keeping them
+// would reveal the recipient's provider. Other Message-IDs are kept.
+func (mailer Mailer) replyReferences(value string, domains []string, from string) string {
+	ids := strings.Fields(strings.ReplaceAll(value, ">", "> "))
+	for i, id := range ids {
+		domain := messageIDDomain(id)
+		for _, own := range domains {
+			if own != "" && (domain == own || strings.HasSuffix(domain, "."+own)) {
+				ids[i] = mailer.replyMessageID(id, from)
+				break
+			}
+		}
+	}
+
+	return strings.Join(ids, " ")
+}
+
+// messageIDDomain returns the lowercase right part of a Message-ID.
+func messageIDDomain(id string) string {
+	id = strings.Trim(strings.TrimSpace(id), "<>")
+	i := strings.LastIndex(id, "@")
+	if i < 0 {
+		return ""
+	}
+
+	return strings.ToLower(id[i+1:])
+}
+
+// replyAddresses returns the To or Cc addresses of a reply that are reply
+// addresses of the alias (alias+token@domain, or the legacy
+// alias+john=example.com@domain), replaced by the address they stand for.
+// Other addresses are dropped, as they would receive the message from the
+// alias without having written to it. An unparsable field is dropped.
+func (mailer Mailer) replyAddresses(value string, alias model.Alias) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	addrs, err := mail.ParseAddressList(value)
+	if err != nil {
+		log.Printf("Warning: dropping unparsable address header: %v", err)
+		return nil
+	}
+
+	list := []string{}
+	for _, addr := range addrs {
+		replyTo, ok := mailer.resolveReplyAddress(addr.Address, alias)
+		if !ok {
+			log.Printf("Warning: dropping reply recipient not addressed through the alias")
+			continue
+		}
+		addr.Address = replyTo
+		list = append(list, addr.String())
+	}
+
+	return list
+}
+
+// resolveReplyAddress returns the address a reply address of the alias
+// stands for.
+func (mailer Mailer) resolveReplyAddress(address string, alias model.Alias) (string, bool) {
+	if name, _, ok := model.ParseContactAddress(address); ok && mailer.contacts != nil && strings.EqualFold(name, alias.Name) {
+		if replyTo, ok := mailer.contacts.ResolveReplyAddress(address); ok {
+			return replyTo, true
+		}
+	}
+
+	name, replyTo := model.ParseReplyTo(address)
+	if !strings.EqualFold(name, alias.Name) {
+		return "", false
+	}
+
+	return replyTo, utils.ValidateEmail(replyTo) == nil
+}
+
+// replyAddress returns the reply address of a correspondent of the alias.
+func (mailer Mailer) replyAddress(alias model.Alias, address string) (string, error) {
+	if mailer.contacts != nil {
+		return mailer.contacts.ReplyAddress(alias, address)
+	}
+
+	return model.GenerateReplyTo(alias.Name, address), nil
+}
+
+// forwardCc returns the other To and Cc participants of a forwarded message
+// as reply addresses of the alias, so replying to all from the recipient
+// reaches each of them through the alias.
+func (mailer Mailer) forwardCc(data []byte, alias model.Alias, rcpt string) []string {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+
+	list := []string{}
+	for _, field := range []string{"To", "Cc"} {
+		addrs, err := msg.Header.AddressList(field)
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			if strings.EqualFold(addr.Address, alias.Name) || strings.EqualFold(addr.Address, rcpt) {
+				continue
+			}
+			if name, replyTo := model.ParseReplyTo(addr.Address); replyTo != "" && strings.EqualFold(name, alias.Name) {
+				continue
+			}
+			if name, _, ok := model.ParseContactAddress(addr.Address); ok && strings.EqualFold(name, alias.Name) {
+				continue
+			}
+
+			replyTo, err := mailer.replyAddress(alias, addr.Address)
+			if err != nil {
+				log.Printf("Warning: dropping Cc participant: %v", err)
+				continue
+			}
+			addr.Address = replyTo
+			list = append(list, addr.String())
+		}
+	}
+
+	return list
+}
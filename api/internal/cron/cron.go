@@ -4,12 +4,13 @@ import (
 	"log"
 
 	"github.com/jasonlvhit/gocron"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"ivpn.net/email/api/config"
 	"ivpn.net/email/api/internal/cron/jobs"
 )
 
-func New(db *gorm.DB) {
+func New(db *gorm.DB, rdb *redis.Client) {
 	cfg, err := config.New()
 	if err != nil {
 		log.Println("Error loading config:", err)
@@ -46,12 +47,30 @@ func New(db *gorm.DB) {
 		return
 	}
 
+	err = gocron.Every(1).Hour().Do(jobs.ExpireAliases, db, cfg.Service)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
 	err = gocron.Every(1).Hour().Do(jobs.DeleteExpiredSessions, db, cfg.API)
 	if err != nil {
 		log.Println("Error scheduling job:", err)
 		return
 	}
 
+	err = gocron.Every(10).Minutes().Do(jobs.FlushAccessKeyUsage, db, rdb)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
+	err = gocron.Every(1).Hour().Do(jobs.ExpireUnusedAccessKeys, db, cfg.API)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
 	err = gocron.Every(1).Hour().Do(jobs.NotifyExpiringSubscriptionsJob, cfg, db)
 	if err != nil {
 		log.Println("Error scheduling job:", err)
@@ -64,6 +83,24 @@ func New(db *gorm.DB) {
 		return
 	}
 
+	err = gocron.Every(1).Hour().Do(jobs.DeleteExpiredQuarantine, db, cfg.Service)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
+	err = gocron.Every(1).Hour().Do(jobs.DeleteOldAuditEvents, db, cfg.Service)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
+	err = gocron.Every(1).Hour().Do(jobs.DeleteOldDeliveries, db)
+	if err != nil {
+		log.Println("Error scheduling job:", err)
+		return
+	}
+
 	err = gocron.Every(12).Hour().Do(jobs.VerifyDomainsJob, cfg, db)
 	if err != nil {
 		log.Println("Error scheduling job:", err)
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"ivpn.net/email/api/config"
+	"ivpn.net/email/api/internal/model"
+)
+
+const (
+	// Days of access key usage kept in the database
+	accessKeyUsageDays = 90
+)
+
+// Flush access key usage counted in the cache to the database
+func FlushAccessKeyUsage(db *gorm.DB, rdb *redis.Client) {
+	ctx := context.Background()
+
+	iter := rdb.Scan(ctx, 0, model.AccessKeyRequestsKey+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		id, date, ok := strings.Cut(strings.TrimPrefix(key, model.AccessKeyRequestsKey), "_")
+		if !ok {
+			continue
+		}
+
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			continue
+		}
+
+		// Requests counted after GETDEL start a new key for the next run
+		requests, err := rdb.GetDel(ctx, key).Int()
+		if err != nil {
+			if err != redis.Nil {
+				log.Println("Error getting access key requests:", err)
+			}
+			continue
+		}
+
+		usage := model.AccessKeyUsage{AccessKeyID: id, Date: day, Requests: requests}
+		err = db.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{"requests": gorm.Expr("requests + ?", requests)}),
+		}).Create(&usage).Error
+		if err != nil {
+			log.Println("Error saving access key usage:", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Println("Error scanning access key requests:", err)
+	}
+
+	iter = rdb.Scan(ctx, 0, model.AccessKeyLastUsedKey+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := rdb.GetDel(ctx, key).Result()
+		if err != nil {
+			if err != redis.Nil {
+				log.Println("Error getting access key last use:", err)
+			}
+			continue
+		}
+
+		unix, ip, _ := strings.Cut(value, " ")
+		seconds, err := strconv.ParseInt(unix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		id := strings.TrimPrefix(key, model.AccessKeyLastUsedKey)
+		err = db.Model(&model.AccessKey{}).Where("id = ?", id).Updates(map[string]any{
+			"last_used_at": time.Unix(seconds, 0).UTC(),
+			"last_used_ip": ip,
+		}).Error
+		if err != nil {
+			log.Println("Error saving access key last use:", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		log.Println("Error scanning access key last use:", err)
+	}
+
+	err := db.Where("date < ?", time.Now().UTC().AddDate(0, 0, -accessKeyUsageDays)).Delete(&model.AccessKeyUsage{}).Error
+	if err != nil {
+		log.Println("Error deleting old access key usage:", err)
+	}
+}
+
+// Expire access keys unused for ACCESS_KEY_UNUSED_DAYS. Keys never used are
+// measured from when usage tracking started.
+func ExpireUnusedAccessKeys(db *gorm.DB, cfg config.APIConfig) {
+	if cfg.AccessKeyUnusedDays <= 0 {
+		return
+	}
+
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -cfg.AccessKeyUnusedDays)
+	err := db.Model(&model.AccessKey{}).
+		Where("(expires_at IS NULL OR expires_at > ?) AND COALESCE(last_used_at, tracked_since) < ?", now, cutoff).
+		Update("expires_at", now).Error
+	if err != nil {
+		log.Println("Error expiring unused access keys:", err)
+	}
+}
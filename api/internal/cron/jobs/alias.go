@@ -4,9 +4,13 @@ import (
 	"log"
 
 	"gorm.io/gorm"
+	"ivpn.net/email/api/config"
 	"ivpn.net/email/api/internal/model"
 )
 
+// expiredAliases matches aliases past their expiration date or message limit
+const expiredAliases = "(expires_at IS NOT NULL AND expires_at < NOW()) OR (max_messages > 0 AND received >= max_messages)"
+
 // Cleanup deleted aliases older than 90 days
 func CleanupDeletedAliases(db *gorm.DB) {
 	err := db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < NOW() - INTERVAL ? DAY", 90).Delete(&model.Alias{}).Error
@@ -15,3 +19,26 @@ func CleanupDeletedAliases(db *gorm.DB) {
 		return
 	}
 }
+
+// Disable or delete expired aliases, as set by ALIAS_EXPIRED_ACTION
+func ExpireAliases(db *gorm.DB, cfg config.ServiceConfig) {
+	if cfg.AliasExpiredAction != "delete" {
+		err := db.Model(&model.Alias{}).Where("enabled = ?", true).Where(expiredAliases).Update("enabled", false).Error
+		if err != nil {
+			log.Println("Error disabling expired aliases:", err)
+		}
+		return
+	}
+
+	err := db.Where("alias_id IN (?)", db.Model(&model.Alias{}).Select("id").Where(expiredAliases)).Delete(&model.Contact{}).Error
+	if err != nil {
+		log.Println("Error deleting contacts of expired aliases:", err)
+		return
+	}
+
+	err = db.Where(expiredAliases).Delete(&model.Alias{}).Error
+	if err != nil {
+		log.Println("Error deleting expired aliases:", err)
+		return
+	}
+}
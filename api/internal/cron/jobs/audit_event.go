@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"ivpn.net/email/api/config"
+	"ivpn.net/email/api/internal/model"
+)
+
+// Delete audit events older than AUDIT_DAYS
+func DeleteOldAuditEvents(db *gorm.DB, cfg config.ServiceConfig) {
+	if cfg.AuditDays <= 0 {
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -cfg.AuditDays)
+	err := db.Where("created_at < ?", cutoff).Delete(&model.AuditEvent{}).Error
+	if err != nil {
+		log.Println("Error deleting old audit events:", err)
+	}
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+	"ivpn.net/email/api/internal/model"
+)
+
+// Idempotency keys are kept longer than the Postfix queue lifetime (5 days)
+const DeliveryExpDays = 7
+
+// Delete finished (sent or dead-lettered) deliveries
+func DeleteOldDeliveries(db *gorm.DB) {
+	err := db.Where("status <> ? AND created_at < NOW() - INTERVAL ? DAY", model.DeliveryPending, DeliveryExpDays).Delete(&model.Delivery{}).Error
+	if err != nil {
+		log.Println("Error deleting old deliveries:", err)
+		return
+	}
+}
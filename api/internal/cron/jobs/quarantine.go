@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+	"ivpn.net/email/api/config"
+	"ivpn.net/email/api/internal/model"
+)
+
+const (
+	QuarantineBaseDir = "/var/log/quarantine"
+)
+
+// Delete quarantined messages past their retention
+func DeleteExpiredQuarantine(db *gorm.DB, cfg config.ServiceConfig) {
+	var messages []model.Quarantine
+	err := db.Where("expires_at < NOW()").Find(&messages).Error
+	if err != nil {
+		log.Println("Error getting expired quarantined messages:", err)
+		return
+	}
+
+	for _, message := range messages {
+		err := os.Remove(filepath.Join(QuarantineBaseDir, message.ID+".eml"))
+		if err != nil && !os.IsNotExist(err) {
+			log.Println("Error deleting quarantine file:", err)
+		}
+	}
+
+	err = db.Where("expires_at < NOW()").Delete(&model.Quarantine{}).Error
+	if err != nil {
+		log.Println("Error deleting expired quarantined messages:", err)
+	}
+
+	// Files of deleted users and failed writes
+	err = cleanupOldQuarantineFiles(time.Duration(cfg.QuarantineDays+1) * 24 * time.Hour)
+	if err != nil {
+		log.Println("Error cleaning up old quarantine files:", err)
+	}
+}
+
+func cleanupOldQuarantineFiles(maxAge time.Duration) error {
+	entries, err := os.ReadDir(QuarantineBaseDir)
+	if os.IsNotExist(err) {
+		return nil // Nothing to clean up
+	}
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || info.IsDir() || !info.ModTime().Before(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(QuarantineBaseDir, entry.Name())
+		if err := os.Remove(path); err != nil {
+			log.Println("Error deleting file:", path, err)
+		}
+	}
+
+	return nil
+}
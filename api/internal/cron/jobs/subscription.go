@@ -96,6 +96,7 @@ func sendSubscriptionExpiryEmail(cfg config.Config, db *gorm.DB, sub model.Subsc
 			"from": cfg.SMTPClient.SenderName,
 		}
 		mailer := mailer.New(cfg.SMTPClient)
+		defer mailer.Close()
 		err = mailer.SendTemplate(user.Email, "Limited Access Mode", "expiring_sub.tmpl", data)
 		if err != nil {
 			log.Printf("error sending expiring subscription email: %s", err.Error())
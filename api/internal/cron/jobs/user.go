@@ -97,6 +97,14 @@ func deleteUsers(db *gorm.DB, users []model.User) {
 			return
 		}
 
+		// Delete access key usage of the user
+		keys := db.Model(&model.AccessKey{}).Select("id").Where("user_id = ?", ID)
+		err = db.Where("access_key_id IN (?)", keys).Delete(&model.AccessKeyUsage{}).Error
+		if err != nil {
+			log.Println("Error deleting access key usage of user:", err)
+			return
+		}
+
 		// Delete access keys of the user
 		err = db.Where("user_id = ?", ID).Delete(&model.AccessKey{}).Error
 		if err != nil {
@@ -111,6 +119,48 @@ func deleteUsers(db *gorm.DB, users []model.User) {
 			return
 		}
 
+		// Delete sender rules of the user
+		err = db.Where("user_id = ?", ID).Delete(&model.Rule{}).Error
+		if err != nil {
+			log.Println("Error deleting sender rules of user:", err)
+			return
+		}
+
+		// Delete filters of the user
+		err = db.Where("user_id = ?", ID).Delete(&model.Filter{}).Error
+		if err != nil {
+			log.Println("Error deleting filters of user:", err)
+			return
+		}
+
+		// Delete DKIM keys of the user
+		err = db.Where("user_id = ?", ID).Delete(&model.DomainKey{}).Error
+		if err != nil {
+			log.Println("Error deleting DKIM keys of user:", err)
+			return
+		}
+
+		// Delete contacts of the user
+		err = db.Where("user_id = ?", ID).Delete(&model.Contact{}).Error
+		if err != nil {
+			log.Println("Error deleting contacts of user:", err)
+			return
+		}
+
+		// Delete quarantined messages of the user, their files expire
+		err = db.Where("user_id = ?", ID).Delete(&model.Quarantine{}).Error
+		if err != nil {
+			log.Println("Error deleting quarantined messages of user:", err)
+			return
+		}
+
+		// Delete audit events of the user
+		err = db.Where("user_id = ?", ID).Delete(&model.AuditEvent{}).Error
+		if err != nil {
+			log.Println("Error deleting audit events of user:", err)
+			return
+		}
+
 		// Delete the user
 		err = db.Where("id = ?", ID).Delete(&model.User{}).Error
 		if err != nil {
@@ -27,6 +27,14 @@ const (
 	AUTHN_TEMP_COOKIE = "authntemp"
 	PA_SESSION_COOKIE = "pasession"
 	USER_ID           = "user_id"
+	SESSION_ID        = "session_id"
+	ACCESS_KEY_ID     = "access_key_id"
+	ACCESS_KEY        = "access_key"
+)
+
+const (
+	ErrAccessKeyIP    = "Access key is not allowed from this IP address."
+	ErrAccessKeyScope = "Access key does not have the required scope."
 )
 
 type Cache interface {
@@ -36,17 +44,27 @@ type Cache interface {
 type Service interface {
 	GetSession(context.Context, string) (model.Session, bool, error)
 	GetUser(context.Context, string) (model.User, error)
+	TouchSession(context.Context, model.Session, string)
 }
 
+type AccessKeyService interface {
+	GetSessionAccessKey(context.Context, string) (model.AccessKey, error)
+	RecordAccessKeyUsage(context.Context, model.AccessKey, string)
+}
+
+// New authenticates the web app by its session cookie. Only signed in
+// sessions are accepted, not WebAuthn ceremonies or access key sessions.
 func New(cfg config.APIConfig, cache Cache, service Service) fiber.Handler {
 
 	return func(c *fiber.Ctx) error {
 		if c.Cookies(AUTHN_COOKIE) != "" {
 			session, ok, err := service.GetSession(c.Context(), c.Cookies(AUTHN_COOKIE))
-			if err == nil && ok {
+			if err == nil && ok && session.SignedIn && session.AccessKeyID == "" {
 				user, err := service.GetUser(c.Context(), session.UserID)
 				if err == nil {
+					service.TouchSession(c.Context(), session, c.IP())
 					c.Locals(USER_ID, user.ID)
+					c.Locals(SESSION_ID, session.ID)
 					return c.Next()
 				}
 			}
@@ -106,7 +124,10 @@ func NewAPIAuth(cfg config.APIConfig, service Service) fiber.Handler {
 			if err == nil && ok {
 				user, err := service.GetUser(c.Context(), session.UserID)
 				if err == nil {
+					service.TouchSession(c.Context(), session, c.IP())
 					c.Locals(USER_ID, user.ID)
+					c.Locals(SESSION_ID, session.ID)
+					c.Locals(ACCESS_KEY_ID, session.AccessKeyID)
 					return c.Next()
 				}
 			}
@@ -116,6 +137,50 @@ func NewAPIAuth(cfg config.APIConfig, service Service) fiber.Handler {
 	}
 }
 
+// NewAccessKeyAuth checks that the access key of a session authenticated by
+// NewAPIAuth still exists and is used from one of its allowed IPs, records the
+// request in its usage and keeps it for NewScope.
+func NewAccessKeyAuth(service AccessKeyService) fiber.Handler {
+
+	return func(c *fiber.Ctx) error {
+		id, _ := c.Locals(ACCESS_KEY_ID).(string)
+		if id == "" {
+			return c.Next()
+		}
+
+		accessKey, err := service.GetSessionAccessKey(c.Context(), id)
+		if err != nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+
+		if !accessKey.AllowsIP(c.IP()) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrAccessKeyIP,
+			})
+		}
+
+		service.RecordAccessKeyUsage(c.Context(), accessKey, c.IP())
+
+		c.Locals(ACCESS_KEY, accessKey)
+		return c.Next()
+	}
+}
+
+// NewScope restricts a route of the /v1/api group to access keys with scope.
+func NewScope(scope string) fiber.Handler {
+
+	return func(c *fiber.Ctx) error {
+		accessKey, ok := c.Locals(ACCESS_KEY).(model.AccessKey)
+		if ok && !accessKey.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": ErrAccessKeyScope,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 func NewCookieAuthn(token string, path string, cfg config.APIConfig) *fiber.Cookie {
 	return &fiber.Cookie{
 		Name:     AUTHN_COOKIE,
@@ -169,6 +234,11 @@ func GetUserID(c *fiber.Ctx) string {
 	return c.Locals(USER_ID).(string)
 }
 
+func GetSessionID(c *fiber.Ctx) string {
+	ID, _ := c.Locals(SESSION_ID).(string)
+	return ID
+}
+
 func GetAuthnCookie(c *fiber.Ctx) string {
 	return c.Cookies(AUTHN_COOKIE)
 }
@@ -1,10 +1,14 @@
 package auth
 
 import (
+	"context"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/valyala/fasthttp"
+	"ivpn.net/email/api/config"
+	"ivpn.net/email/api/internal/model"
 )
 
 func TestGetUserID(t *testing.T) {
@@ -166,3 +170,136 @@ func TestGetAuthnCookie(t *testing.T) {
 		})
 	}
 }
+
+func TestNewScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		accessKey      any
+		expectedStatus int
+	}{
+		{
+			name:           "No access key",
+			accessKey:      nil,
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Access key without scopes",
+			accessKey:      model.AccessKey{},
+			expectedStatus: fiber.StatusForbidden,
+		},
+		{
+			name:           "Access key with full access",
+			accessKey:      model.AccessKey{Scopes: []string{model.ScopeAll}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Access key with scope",
+			accessKey:      model.AccessKey{Scopes: []string{model.ScopeAliasesCreate}},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Access key without scope",
+			accessKey:      model.AccessKey{Scopes: []string{model.ScopeAliasesRead}},
+			expectedStatus: fiber.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(func(c *fiber.Ctx) error {
+				if tt.accessKey != nil {
+					c.Locals(ACCESS_KEY, tt.accessKey)
+				}
+				return c.Next()
+			})
+			app.Post("/alias", NewScope(model.ScopeAliasesCreate), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			resp, err := app.Test(httptest.NewRequest("POST", "/alias", nil))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestGetSessionID(t *testing.T) {
+	app := fiber.New()
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	defer app.ReleaseCtx(c)
+
+	if ID := GetSessionID(c); ID != "" {
+		t.Errorf("expected empty session ID, got %s", ID)
+	}
+
+	c.Locals(SESSION_ID, "12345")
+	if ID := GetSessionID(c); ID != "12345" {
+		t.Errorf("expected 12345, got %s", ID)
+	}
+}
+
+type testService struct {
+	session model.Session
+}
+
+func (s testService) GetSession(ctx context.Context, token string) (model.Session, bool, error) {
+	return s.session, true, nil
+}
+
+func (s testService) GetUser(ctx context.Context, ID string) (model.User, error) {
+	user := model.User{}
+	user.ID = ID
+	return user, nil
+}
+
+func (s testService) TouchSession(ctx context.Context, session model.Session, ip string) {}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		session        model.Session
+		expectedStatus int
+	}{
+		{
+			name:           "Signed in session",
+			session:        model.Session{UserID: "12345", SignedIn: true},
+			expectedStatus: fiber.StatusOK,
+		},
+		{
+			name:           "Access key session",
+			session:        model.Session{UserID: "12345", SignedIn: true, AccessKeyID: "67890"},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+		{
+			name:           "Ceremony session",
+			session:        model.Session{UserID: "12345"},
+			expectedStatus: fiber.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/user", New(config.APIConfig{}, nil, testService{session: tt.session}), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/user", nil)
+			req.Header.Set("Cookie", AUTHN_COOKIE+"=token")
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
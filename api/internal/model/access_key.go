@@ -3,25 +3,96 @@ package model
 import (
 	"crypto/rand"
 	"errors"
+	"net/netip"
+	"slices"
+	"strings"
 	"time"
 
 	"ivpn.net/email/api/internal/utils"
 )
 
 var (
-	ErrTokenHashFailed = errors.New("token hash failed")
+	ErrTokenHashFailed  = errors.New("token hash failed")
+	ErrInvalidScope     = errors.New("invalid access key scope")
+	ErrMissingScope     = errors.New("access key requires at least one scope")
+	ErrInvalidAllowedIP = errors.New("invalid access key allowed IP, expected an IP address or CIDR")
 )
 
+// Access key scopes, ScopeAll grants them all
+const (
+	ScopeAll             = "all"
+	ScopeAliasesRead     = "aliases:read"
+	ScopeAliasesCreate   = "aliases:create"
+	ScopeAliasesWrite    = "aliases:write"
+	ScopeAliasesDelete   = "aliases:delete"
+	ScopeRulesRead       = "rules:read"
+	ScopeRulesWrite      = "rules:write"
+	ScopeDomainsRead     = "domains:read"
+	ScopeDomainsWrite    = "domains:write"
+	ScopeRecipientsRead  = "recipients:read"
+	ScopeRecipientsWrite = "recipients:write"
+	ScopeLogsRead        = "logs:read"
+	ScopeSettingsRead    = "settings:read"
+	ScopeSettingsWrite   = "settings:write"
+)
+
+var Scopes = []string{
+	ScopeAll,
+	ScopeAliasesRead,
+	ScopeAliasesCreate,
+	ScopeAliasesWrite,
+	ScopeAliasesDelete,
+	ScopeRulesRead,
+	ScopeRulesWrite,
+	ScopeDomainsRead,
+	ScopeDomainsWrite,
+	ScopeRecipientsRead,
+	ScopeRecipientsWrite,
+	ScopeLogsRead,
+	ScopeSettingsRead,
+	ScopeSettingsWrite,
+}
+
+// AliasScopes are the scopes of access keys created before scopes existed,
+// when /v1/api only served aliases.
+var AliasScopes = []string{
+	ScopeAliasesRead,
+	ScopeAliasesCreate,
+	ScopeAliasesWrite,
+	ScopeAliasesDelete,
+}
+
 type AccessKey struct {
 	BaseModel
-	UserId     string     `json:"user_id"`
-	TokenHash  string     `json:"-"`
-	TokenId    string     `gorm:"-" json:"-"`
-	TokenPlain *string    `gorm:"-" json:"-"`
-	Name       string     `json:"name"`
-	ExpiresAt  *time.Time `json:"expires_at"` // nullable
+	UserId       string           `json:"user_id"`
+	TokenHash    string           `json:"-"`
+	TokenId      string           `gorm:"-" json:"-"`
+	TokenPlain   *string          `gorm:"-" json:"-"`
+	Name         string           `json:"name"`
+	ExpiresAt    *time.Time       `json:"expires_at"` // nullable
+	Scopes       []string         `gorm:"type:text;serializer:json" json:"scopes"`
+	AllowedIPs   []string         `gorm:"type:text;serializer:json" json:"allowed_ips"`
+	LastUsedAt   *time.Time       `json:"last_used_at"`
+	LastUsedIP   string           `gorm:"size:45" json:"last_used_ip"`
+	TrackedSince *time.Time       `json:"-"` // baseline of keys never used
+	Usage        []AccessKeyUsage `gorm:"-" json:"usage"`
+}
+
+// AccessKeyUsage is the number of /v1/api requests made with an access key
+// on a day (UTC).
+type AccessKeyUsage struct {
+	AccessKeyID string    `gorm:"size:36;primaryKey" json:"-"`
+	Date        time.Time `gorm:"type:date;primaryKey" json:"date"`
+	Requests    int       `json:"requests"`
 }
 
+// Cache keys of access key usage, counted per request and flushed to the
+// database by a cron job
+const (
+	AccessKeyRequestsKey = "access_key_requests_"  // <id>_<date>
+	AccessKeyLastUsedKey = "access_key_last_used_" // <id>, "<unix time> <ip>"
+)
+
 func (a *AccessKey) SetToken(token string) error {
 	hash, err := utils.HashPassword(token)
 	if err != nil {
@@ -50,6 +121,81 @@ func (a *AccessKey) IsExpired() bool {
 	return time.Now().After(*a.ExpiresAt)
 }
 
+// HasScope reports whether the key grants scope. A key without scopes grants
+// none.
+func (a *AccessKey) HasScope(scope string) bool {
+	return slices.Contains(a.Scopes, ScopeAll) || slices.Contains(a.Scopes, scope)
+}
+
+// AllowsIP reports whether the key may be used from ip. A key without allowed
+// IPs may be used from anywhere.
+func (a *AccessKey) AllowsIP(ip string) bool {
+	if len(a.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, allowed := range a.AllowedIPs {
+		prefix, err := parseAllowedIP(allowed)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateScopes reports whether there is at least one scope and all scopes
+// are known.
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrMissingScope
+	}
+
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return ErrInvalidScope
+		}
+	}
+
+	return nil
+}
+
+// ValidateAllowedIPs reports whether all entries are IP addresses or CIDRs.
+func ValidateAllowedIPs(ips []string) error {
+	for _, ip := range ips {
+		if _, err := parseAllowedIP(ip); err != nil {
+			return ErrInvalidAllowedIP
+		}
+	}
+
+	return nil
+}
+
+func parseAllowedIP(value string) (netip.Prefix, error) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
 func GenAccessKeyToken() (string, error) {
 	token, err := GenToken(48)
 	if err != nil {
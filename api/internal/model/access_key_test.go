@@ -107,3 +107,79 @@ func TestGenToken(t *testing.T) {
 		}
 	})
 }
+
+func TestAccessKeyHasScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{name: "no scopes grants none", scopes: nil, scope: ScopeAliasesDelete, want: false},
+		{name: "full access", scopes: []string{ScopeAll}, scope: ScopeSettingsWrite, want: true},
+		{name: "granted scope", scopes: []string{ScopeAliasesCreate}, scope: ScopeAliasesCreate, want: true},
+		{name: "missing scope", scopes: []string{ScopeAliasesCreate}, scope: ScopeAliasesRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessKey := &AccessKey{Scopes: tt.scopes}
+			if got := accessKey.HasScope(tt.scope); got != tt.want {
+				t.Errorf("HasScope(%q) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessKeyAllowsIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowedIPs []string
+		ip         string
+		want       bool
+	}{
+		{name: "no allowed IPs", allowedIPs: nil, ip: "203.0.113.7", want: true},
+		{name: "single IP", allowedIPs: []string{"203.0.113.7"}, ip: "203.0.113.7", want: true},
+		{name: "other IP", allowedIPs: []string{"203.0.113.7"}, ip: "203.0.113.8", want: false},
+		{name: "CIDR", allowedIPs: []string{"198.51.100.0/24"}, ip: "198.51.100.42", want: true},
+		{name: "outside CIDR", allowedIPs: []string{"198.51.100.0/24"}, ip: "198.51.101.1", want: false},
+		{name: "IPv4-mapped IPv6", allowedIPs: []string{"203.0.113.7"}, ip: "::ffff:203.0.113.7", want: true},
+		{name: "IPv6 CIDR", allowedIPs: []string{"2001:db8::/32"}, ip: "2001:db8::1", want: true},
+		{name: "invalid IP", allowedIPs: []string{"203.0.113.7"}, ip: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessKey := &AccessKey{AllowedIPs: tt.allowedIPs}
+			if got := accessKey.AllowsIP(tt.ip); got != tt.want {
+				t.Errorf("AllowsIP(%q) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	if err := ValidateScopes([]string{ScopeAliasesCreate, ScopeRulesRead}); err != nil {
+		t.Errorf("ValidateScopes() error = %v, want nil", err)
+	}
+
+	if err := ValidateScopes([]string{"aliases:admin"}); err != ErrInvalidScope {
+		t.Errorf("ValidateScopes() error = %v, want %v", err, ErrInvalidScope)
+	}
+
+	if err := ValidateScopes(nil); err != ErrMissingScope {
+		t.Errorf("ValidateScopes() error = %v, want %v", err, ErrMissingScope)
+	}
+}
+
+func TestValidateAllowedIPs(t *testing.T) {
+	if err := ValidateAllowedIPs([]string{"203.0.113.7", "198.51.100.0/24", "2001:db8::/32"}); err != nil {
+		t.Errorf("ValidateAllowedIPs() error = %v, want nil", err)
+	}
+
+	for _, ip := range []string{"203.0.113", "198.51.100.0/33", "localhost"} {
+		if err := ValidateAllowedIPs([]string{ip}); err != ErrInvalidAllowedIP {
+			t.Errorf("ValidateAllowedIPs(%q) error = %v, want %v", ip, err, ErrInvalidAllowedIP)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -21,6 +22,13 @@ type Alias struct {
 	Recipients       string         `gorm:"default:''" json:"recipients"`
 	FromName         string         `gorm:"default:''" json:"from_name"`
 	CatchAll         bool           `json:"catch_all"`
+	SpamPolicy       SpamPolicy     `gorm:"default:''" json:"spam_policy"`
+	PausedAt         *time.Time     `json:"paused_at"`
+	ThrottledUntil   *time.Time     `json:"throttled_until"`
+	ExpiresAt        *time.Time     `json:"expires_at"`
+	MaxMessages      int            `gorm:"default:0" json:"max_messages"`
+	Received         int            `gorm:"default:0" json:"received"`
+	Schedule         *AliasSchedule `gorm:"type:text;serializer:json" json:"schedule"`
 	Stats            AliasStats     `gorm:"-" json:"stats"`
 	IsCustomDomain   bool           `gorm:"-" json:"is_custom_domain"`
 	IsDomainVerified *bool          `gorm:"-" json:"is_domain_verified"`
@@ -28,13 +36,31 @@ type Alias struct {
 }
 
 type AliasStats struct {
-	Forwards int `json:"forwards"`
-	Blocks   int `json:"blocks"`
-	Replies  int `json:"replies"`
-	Sends    int `json:"sends"`
+	Forwards   int `json:"forwards"`
+	Blocks     int `json:"blocks"`
+	Replies    int `json:"replies"`
+	Sends      int `json:"sends"`
+	Duplicates int `json:"duplicates"`
+	Spam       int `json:"spam"`
 }
 
 type AliasList struct {
 	Aliases []Alias `json:"aliases"`
 	Total   int     `json:"total"`
 }
+
+// Throttled reports whether forwards to the alias are dropped after it
+// exceeded the inbound rate limit.
+func (a Alias) Throttled(now time.Time) bool {
+	return a.ThrottledUntil != nil && now.Before(*a.ThrottledUntil)
+}
+
+// Expired reports whether the alias is past its expiration date or has
+// received its maximum number of messages.
+func (a Alias) Expired(now time.Time) bool {
+	if a.ExpiresAt != nil && !now.Before(*a.ExpiresAt) {
+		return true
+	}
+
+	return a.MaxMessages > 0 && a.Received >= a.MaxMessages
+}
@@ -0,0 +1,146 @@
+package model
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidScheduleTime     = errors.New("invalid schedule time, expected HH:MM")
+	ErrInvalidScheduleDay      = errors.New("invalid schedule day, expected 0 (Sunday) to 6 (Saturday)")
+	ErrInvalidScheduleTimeZone = errors.New("invalid schedule time zone")
+	ErrInvalidScheduleAction   = errors.New("invalid schedule action, expected block or hold")
+)
+
+type ScheduleAction string
+
+const (
+	ScheduleBlock ScheduleAction = "block"
+	ScheduleHold  ScheduleAction = "hold"
+)
+
+// scheduleTimeLayout is the layout of the start and end of a window.
+const scheduleTimeLayout = "15:04"
+
+// AliasSchedule restricts when an alias accepts mail: on Days (every day when
+// empty) between Start and End in TimeZone, and until Until. A window with
+// End before Start ends on the next day, no Start and End means all day. Mail
+// outside the window is blocked or held until it opens (Action).
+type AliasSchedule struct {
+	Days     []time.Weekday `json:"days"`
+	Start    string         `json:"start"`
+	End      string         `json:"end"`
+	TimeZone string         `json:"time_zone"`
+	Until    *time.Time     `json:"until"`
+	Action   ScheduleAction `json:"action"`
+}
+
+func (s AliasSchedule) Validate() error {
+	for _, value := range []string{s.Start, s.End} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(scheduleTimeLayout, value); err != nil {
+			return ErrInvalidScheduleTime
+		}
+	}
+
+	for _, day := range s.Days {
+		if day < time.Sunday || day > time.Saturday {
+			return ErrInvalidScheduleDay
+		}
+	}
+
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return ErrInvalidScheduleTimeZone
+	}
+
+	if s.Action != "" && s.Action != ScheduleBlock && s.Action != ScheduleHold {
+		return ErrInvalidScheduleAction
+	}
+
+	return nil
+}
+
+// Active reports whether the alias accepts mail at now.
+func (s AliasSchedule) Active(now time.Time) bool {
+	if s.Until != nil && !now.Before(*s.Until) {
+		return false
+	}
+
+	t := now.In(s.location())
+	minute := t.Hour()*60 + t.Minute()
+	start, end := s.minutes()
+
+	switch {
+	case start == end:
+		return s.onDay(t.Weekday())
+	case start < end:
+		return s.onDay(t.Weekday()) && minute >= start && minute < end
+	default:
+		// Overnight window, the part after midnight belongs to the day before
+		return (s.onDay(t.Weekday()) && minute >= start) ||
+			(s.onDay((t.Weekday()+6)%7) && minute < end)
+	}
+}
+
+// NextActive returns when the alias accepts mail again after now, or now if it
+// does already. ok is false when it never will.
+func (s AliasSchedule) NextActive(now time.Time) (time.Time, bool) {
+	if s.Active(now) {
+		return now, true
+	}
+
+	t := now.In(s.location())
+	start, _ := s.minutes()
+	for i := range 8 {
+		day := time.Date(t.Year(), t.Month(), t.Day()+i, start/60, start%60, 0, 0, t.Location())
+		if !day.After(now) || !s.onDay(day.Weekday()) {
+			continue
+		}
+
+		if s.Until != nil && !day.Before(*s.Until) {
+			return time.Time{}, false
+		}
+
+		return day, true
+	}
+
+	return time.Time{}, false
+}
+
+// GetAction returns the action for mail outside the window, block by default.
+func (s AliasSchedule) GetAction() ScheduleAction {
+	if s.Action == "" {
+		return ScheduleBlock
+	}
+
+	return s.Action
+}
+
+func (s AliasSchedule) location() *time.Location {
+	loc, err := time.LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+func (s AliasSchedule) minutes() (int, int) {
+	return scheduleMinute(s.Start), scheduleMinute(s.End)
+}
+
+func (s AliasSchedule) onDay(day time.Weekday) bool {
+	return len(s.Days) == 0 || slices.Contains(s.Days, day)
+}
+
+func scheduleMinute(value string) int {
+	t, err := time.Parse(scheduleTimeLayout, value)
+	if err != nil {
+		return 0
+	}
+
+	return t.Hour()*60 + t.Minute()
+}
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAliasScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule AliasSchedule
+		wantErr  error
+	}{
+		{name: "empty", schedule: AliasSchedule{}, wantErr: nil},
+		{name: "weekdays", schedule: AliasSchedule{Days: []time.Weekday{1, 2, 3, 4, 5}, Start: "09:00", End: "17:00", TimeZone: "Europe/Berlin", Action: ScheduleHold}, wantErr: nil},
+		{name: "invalid time", schedule: AliasSchedule{Start: "9am"}, wantErr: ErrInvalidScheduleTime},
+		{name: "invalid day", schedule: AliasSchedule{Days: []time.Weekday{7}}, wantErr: ErrInvalidScheduleDay},
+		{name: "invalid time zone", schedule: AliasSchedule{TimeZone: "Mars/Olympus"}, wantErr: ErrInvalidScheduleTimeZone},
+		{name: "invalid action", schedule: AliasSchedule{Action: "drop"}, wantErr: ErrInvalidScheduleAction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); err != tt.wantErr {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAliasScheduleActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	weekdays := AliasSchedule{Days: []time.Weekday{1, 2, 3, 4, 5}, Start: "09:00", End: "17:00", TimeZone: "Europe/Berlin"}
+	nights := AliasSchedule{Days: []time.Weekday{5}, Start: "22:00", End: "06:00", TimeZone: "Europe/Berlin"}
+	until := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	limited := AliasSchedule{Until: &until}
+
+	tests := []struct {
+		name     string
+		schedule AliasSchedule
+		now      time.Time
+		want     bool
+	}{
+		{name: "weekday in window", schedule: weekdays, now: time.Date(2026, 2, 2, 10, 0, 0, 0, berlin), want: true},
+		{name: "weekday before window", schedule: weekdays, now: time.Date(2026, 2, 2, 8, 59, 0, 0, berlin), want: false},
+		{name: "weekday at end of window", schedule: weekdays, now: time.Date(2026, 2, 2, 17, 0, 0, 0, berlin), want: false},
+		{name: "weekend", schedule: weekdays, now: time.Date(2026, 2, 7, 10, 0, 0, 0, berlin), want: false},
+		{name: "other time zone", schedule: weekdays, now: time.Date(2026, 2, 2, 8, 30, 0, 0, time.UTC), want: true},
+		{name: "overnight on day", schedule: nights, now: time.Date(2026, 2, 6, 23, 0, 0, 0, berlin), want: true},
+		{name: "overnight after midnight", schedule: nights, now: time.Date(2026, 2, 7, 5, 0, 0, 0, berlin), want: true},
+		{name: "overnight after midnight of other day", schedule: nights, now: time.Date(2026, 2, 6, 5, 0, 0, 0, berlin), want: false},
+		{name: "before until", schedule: limited, now: until.Add(-time.Minute), want: true},
+		{name: "after until", schedule: limited, now: until, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Active(tt.now); got != tt.want {
+				t.Errorf("Active() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasScheduleNextActive(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone data not available")
+	}
+
+	weekdays := AliasSchedule{Days: []time.Weekday{1, 2, 3, 4, 5}, Start: "09:00", End: "17:00", TimeZone: "Europe/Berlin"}
+
+	// Friday evening, the window opens on Monday morning
+	now := time.Date(2026, 2, 6, 18, 0, 0, 0, berlin)
+	next, ok := weekdays.NextActive(now)
+	if want := time.Date(2026, 2, 9, 9, 0, 0, 0, berlin); !ok || !next.Equal(want) {
+		t.Errorf("NextActive() = %v, %v, want %v", next, ok, want)
+	}
+
+	// In the window
+	now = time.Date(2026, 2, 9, 10, 0, 0, 0, berlin)
+	if next, ok := weekdays.NextActive(now); !ok || !next.Equal(now) {
+		t.Errorf("NextActive() = %v, %v, want %v", next, ok, now)
+	}
+
+	// Never again
+	until := time.Date(2026, 2, 7, 0, 0, 0, 0, berlin)
+	weekdays.Until = &until
+	if _, ok := weekdays.NextActive(time.Date(2026, 2, 6, 18, 0, 0, 0, berlin)); ok {
+		t.Error("NextActive() ok = true after until")
+	}
+}
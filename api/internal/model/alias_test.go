@@ -0,0 +1,58 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAliasThrottled(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		until *time.Time
+		want  bool
+	}{
+		{name: "not throttled", until: nil, want: false},
+		{name: "throttle expired", until: &past, want: false},
+		{name: "throttled", until: &future, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alias := Alias{ThrottledUntil: tt.until}
+			if got := alias.Throttled(now); got != tt.want {
+				t.Errorf("Throttled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAliasExpired(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		alias Alias
+		want  bool
+	}{
+		{name: "no limits", alias: Alias{Received: 10}, want: false},
+		{name: "expiration date passed", alias: Alias{ExpiresAt: &past}, want: true},
+		{name: "expiration date ahead", alias: Alias{ExpiresAt: &future}, want: false},
+		{name: "message limit reached", alias: Alias{MaxMessages: 1, Received: 1}, want: true},
+		{name: "message limit not reached", alias: Alias{MaxMessages: 3, Received: 2}, want: false},
+		{name: "message limit reached before date", alias: Alias{ExpiresAt: &future, MaxMessages: 1, Received: 1}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.alias.Expired(now); got != tt.want {
+				t.Errorf("Expired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package model
+
+type AuditEventType string
+
+const (
+	AuditLogin             AuditEventType = "login"
+	AuditPasskeyLogin      AuditEventType = "passkey_login"
+	AuditPasswordChanged   AuditEventType = "password_changed"
+	AuditEmailChanged      AuditEventType = "email_changed"
+	AuditTotpEnabled       AuditEventType = "totp_enabled"
+	AuditTotpDisabled      AuditEventType = "totp_disabled"
+	AuditPasskeyAdded      AuditEventType = "passkey_added"
+	AuditPasskeyDeleted    AuditEventType = "passkey_deleted"
+	AuditAccessKeyCreated  AuditEventType = "access_key_created"
+	AuditAccessKeyDeleted  AuditEventType = "access_key_deleted"
+	AuditDeletionRequested AuditEventType = "deletion_requested"
+)
+
+// AuditEvent is a security-relevant change or sign-in on an account, kept
+// for the user to review.
+type AuditEvent struct {
+	BaseModel
+	UserID    string         `gorm:"index" json:"-"`
+	Type      AuditEventType `gorm:"size:32" json:"type"`
+	Detail    string         `json:"detail"`
+	IP        string         `gorm:"size:45" json:"ip"`
+	UserAgent string         `gorm:"size:255" json:"user_agent"`
+}
+
+type AuditEventList struct {
+	Events []AuditEvent `json:"events"`
+	Total  int          `json:"total"`
+}
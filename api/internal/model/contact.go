@@ -0,0 +1,73 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+)
+
+// ContactTokenLength is the length of the token of a reply address.
+const ContactTokenLength = 16
+
+var contactTokenEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Contact is an external correspondent of an alias. Mail from Address is
+// forwarded from the reply address alias+token@domain, so replies reach
+// Address without it appearing in the recipient's headers. Contacts created
+// to compose a new message have not been seen yet.
+type Contact struct {
+	BaseModel
+	UserID      string     `json:"-"`
+	AliasID     string     `gorm:"index" json:"alias_id"`
+	AliasName   string     `gorm:"size:255;uniqueIndex:idx_contacts_alias_address,priority:1" json:"alias_name"`
+	Address     string     `gorm:"size:255;uniqueIndex:idx_contacts_alias_address,priority:2" json:"address"`
+	Token       string     `gorm:"size:16;uniqueIndex" json:"-"`
+	Blocked     bool       `gorm:"default:false" json:"blocked"`
+	FirstSeenAt *time.Time `json:"first_seen_at"`
+	LastSeenAt  *time.Time `json:"last_seen_at"`
+	Messages    int        `gorm:"default:0" json:"messages"`
+	ReplyTo     string     `gorm:"-" json:"reply_to"`
+}
+
+// NewContactToken returns a random lowercase base32 token.
+func NewContactToken() (string, error) {
+	b := make([]byte, ContactTokenLength*5/8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return contactTokenEncoding.EncodeToString(b), nil
+}
+
+// IsContactToken reports whether s has the form of a contact token.
+func IsContactToken(s string) bool {
+	if len(s) != ContactTokenLength {
+		return false
+	}
+
+	_, err := contactTokenEncoding.DecodeString(strings.ToLower(s))
+	return err == nil
+}
+
+// ContactAddress returns the reply address of a contact of an alias.
+func ContactAddress(alias string, token string) string {
+	return strings.Replace(alias, "@", "+"+token+"@", 1)
+}
+
+// ParseContactAddress splits a reply address into the alias name and the
+// contact token. ok is false for addresses without a token.
+func ParseContactAddress(email string) (alias string, token string, ok bool) {
+	at := strings.LastIndex(email, "@")
+	plus := strings.LastIndex(email[:max(at, 0)], "+")
+	if at < 0 || plus < 0 {
+		return email, "", false
+	}
+
+	token = strings.ToLower(email[plus+1 : at])
+	if !IsContactToken(token) {
+		return email, "", false
+	}
+
+	return email[:plus] + email[at:], token, true
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestNewContactToken(t *testing.T) {
+	token, err := NewContactToken()
+	if err != nil {
+		t.Fatalf("NewContactToken() error = %v", err)
+	}
+
+	if !IsContactToken(token) {
+		t.Errorf("NewContactToken() = %q, not a contact token", token)
+	}
+}
+
+func TestParseContactAddress(t *testing.T) {
+	tests := []struct {
+		email string
+		alias string
+		token string
+		ok    bool
+	}{
+		{"alias+abcdefgh234567ab@mailx.net", "alias@mailx.net", "abcdefgh234567ab", true},
+		{"alias+ABCDEFGH234567AB@mailx.net", "alias@mailx.net", "abcdefgh234567ab", true},
+		{"*+news+abcdefgh234567ab@custom.com", "*+news@custom.com", "abcdefgh234567ab", true},
+		{"alias+john=example.com@mailx.net", "alias+john=example.com@mailx.net", "", false},
+		{"alias+abcdefgh234567a1@mailx.net", "alias+abcdefgh234567a1@mailx.net", "", false},
+		{"alias+short@mailx.net", "alias+short@mailx.net", "", false},
+		{"alias@mailx.net", "alias@mailx.net", "", false},
+		{"invalid", "invalid", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			alias, token, ok := ParseContactAddress(tt.email)
+			if alias != tt.alias || token != tt.token || ok != tt.ok {
+				t.Errorf("ParseContactAddress() = %q, %q, %v, want %q, %q, %v", alias, token, ok, tt.alias, tt.token, tt.ok)
+			}
+		})
+	}
+}
+
+func TestContactAddress(t *testing.T) {
+	got := ContactAddress("alias@mailx.net", "abcdefgh234567ab")
+	if got != "alias+abcdefgh234567ab@mailx.net" {
+		t.Errorf("ContactAddress() = %q", got)
+	}
+
+	alias, token, ok := ParseContactAddress(got)
+	if !ok || alias != "alias@mailx.net" || token != "abcdefgh234567ab" {
+		t.Errorf("ParseContactAddress(ContactAddress()) = %q, %q, %v", alias, token, ok)
+	}
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySent    DeliveryStatus = "sent"
+	DeliveryFailed  DeliveryStatus = "failed"
+)
+
+// Delivery is a single outbound copy of an inbound message (one per recipient),
+// persisted so it can be retried independently of the Postfix handoff.
+type Delivery struct {
+	BaseModel
+	IdempotencyKey string `gorm:"size:64;uniqueIndex"`
+	UserID         string `gorm:"index"`
+	AliasID        string `gorm:"index"`
+	From           string
+	FromName       string
+	Recipient      string
+	Type           MessageType
+	Data           []byte         `gorm:"type:longblob"`
+	Status         DeliveryStatus `gorm:"size:16;index:idx_delivery_status_next"`
+	Attempts       int
+	NextAttemptAt  time.Time `gorm:"index:idx_delivery_status_next"`
+	LockedUntil    *time.Time
+	LockToken      string       `gorm:"size:36;index"`
+	LastError      string       `gorm:"type:text"`
+	Filter         FilterResult `gorm:"type:text;serializer:json"`
+}
+
+// DeliveryKey returns the idempotency key of a delivery. The same raw message
+// handed over again by Postfix (retry after a 4xx) produces the same key for
+// each alias and recipient, so copies already queued are not queued twice.
+func DeliveryKey(data []byte, aliasID string, rcpt string, msgType MessageType) string {
+	h := sha256.New()
+	h.Write(data)
+	h.Write([]byte{0})
+	h.Write([]byte(aliasID))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.ToLower(rcpt)))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.Itoa(int(msgType))))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DedupeKey returns the cache key of a forwarded copy, identified by the
+// Message-ID (or the body hash when there is none), alias and recipient.
+func DedupeKey(messageID string, data []byte, aliasID string, rcpt string) string {
+	id := strings.TrimSpace(messageID)
+	if id == "" {
+		sum := sha256.Sum256(data)
+		id = hex.EncodeToString(sum[:])
+	}
+
+	sum := sha256.Sum256([]byte(id + "\x00" + aliasID + "\x00" + strings.ToLower(rcpt)))
+
+	return "dedupe_" + hex.EncodeToString(sum[:])
+}
+
+// DeliveryBackoff returns the delay before the next attempt after the given
+// number of failed attempts: base, 2*base, 4*base, ... capped at max.
+func DeliveryBackoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeliveryKey(t *testing.T) {
+	data := []byte("From: sender@example.com\r\nSubject: Test\r\n\r\nBody")
+
+	key := DeliveryKey(data, "alias-1", "rcp@example.com", Forward)
+	if len(key) != 64 {
+		t.Fatalf("expected 64 char key, got %d", len(key))
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		aliasID string
+		rcpt    string
+		msgType MessageType
+		same    bool
+	}{
+		{"same message", data, "alias-1", "rcp@example.com", Forward, true},
+		{"recipient case", data, "alias-1", "RCP@example.com", Forward, true},
+		{"different recipient", data, "alias-1", "other@example.com", Forward, false},
+		{"different alias", data, "alias-2", "rcp@example.com", Forward, false},
+		{"different type", data, "alias-1", "rcp@example.com", Reply, false},
+		{"different body", []byte("From: sender@example.com\r\n\r\nOther"), "alias-1", "rcp@example.com", Forward, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeliveryKey(tt.data, tt.aliasID, tt.rcpt, tt.msgType)
+			if (got == key) != tt.same {
+				t.Errorf("DeliveryKey() same = %v, want %v", got == key, tt.same)
+			}
+		})
+	}
+}
+
+func TestDeliveryBackoff(t *testing.T) {
+	base := time.Minute
+	max := time.Hour
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		got := DeliveryBackoff(tt.attempts, base, max)
+		if got != tt.want {
+			t.Errorf("DeliveryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestDedupeKey(t *testing.T) {
+	data := []byte("From: sender@example.com\r\nSubject: Test\r\n\r\nBody")
+	other := []byte("From: sender@example.com\r\nSubject: Test\r\n\r\nOther")
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		same bool
+	}{
+		{
+			name: "same Message-ID, different body",
+			a:    DedupeKey("<id@example.com>", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("<id@example.com>", other, "alias-1", "rcp@example.com"),
+			same: true,
+		},
+		{
+			name: "different Message-ID",
+			a:    DedupeKey("<id@example.com>", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("<other@example.com>", data, "alias-1", "rcp@example.com"),
+			same: false,
+		},
+		{
+			name: "no Message-ID, same body",
+			a:    DedupeKey("", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("", data, "alias-1", "RCP@example.com"),
+			same: true,
+		},
+		{
+			name: "no Message-ID, different body",
+			a:    DedupeKey("", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("", other, "alias-1", "rcp@example.com"),
+			same: false,
+		},
+		{
+			name: "different alias",
+			a:    DedupeKey("<id@example.com>", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("<id@example.com>", data, "alias-2", "rcp@example.com"),
+			same: false,
+		},
+		{
+			name: "different recipient",
+			a:    DedupeKey("<id@example.com>", data, "alias-1", "rcp@example.com"),
+			b:    DedupeKey("<id@example.com>", data, "alias-1", "other@example.com"),
+			same: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.a == tt.b) != tt.same {
+				t.Errorf("DedupeKey() same = %v, want %v", tt.a == tt.b, tt.same)
+			}
+		})
+	}
+}
@@ -29,3 +29,12 @@ type DNSConfig struct {
 	DKIM   []string `json:"dkim_selectors"`
 	Hosts  []string `json:"mx_hosts"`
 }
+
+// DKIMRecord is the DNS TXT record of a DKIM key, Active keys sign outbound mail.
+type DKIMRecord struct {
+	Domain   string `json:"domain"`
+	Selector string `json:"selector"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Active   bool   `json:"active"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+type DomainKeyStatus string
+
+const (
+	DomainKeyPending DomainKeyStatus = "pending" // generated, waiting for the DNS record
+	DomainKeyActive  DomainKeyStatus = "active"  // signing
+	DomainKeyRetired DomainKeyStatus = "retired" // replaced, kept until the next rotation
+)
+
+// DomainKey is a DKIM key generated for a custom domain, published by the
+// user as a TXT record at Name. PrivateKey is the encrypted PEM key.
+type DomainKey struct {
+	BaseModel
+	UserID     string          `json:"-"`
+	DomainID   string          `gorm:"index" json:"domain_id"`
+	Selector   string          `json:"selector"`
+	Algorithm  string          `json:"algorithm"`
+	PrivateKey string          `gorm:"type:text" json:"-"`
+	Value      string          `gorm:"type:text" json:"value"`
+	Status     DomainKeyStatus `json:"status"`
+	Name       string          `gorm:"-" json:"name"`
+}
+
+// NewDomainKeySelector returns a selector unique per minute, e.g. mailx202610181530.
+func NewDomainKeySelector(t time.Time) string {
+	return "mailx" + t.UTC().Format("200601021504")
+}
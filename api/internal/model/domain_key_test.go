@@ -0,0 +1,14 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewDomainKeySelector(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	got := NewDomainKeySelector(time.Date(2026, 10, 18, 17, 30, 45, 0, loc))
+	if got != "mailx202610181530" {
+		t.Errorf("NewDomainKeySelector() = %q, want %q", got, "mailx202610181530")
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+)
+
+// NullSender is what Postfix passes as ${sender} for the null reverse-path (<>).
+const NullSender = "MAILER-DAEMON"
+
+// Envelope holds the SMTP envelope (MAIL FROM / RCPT TO) of an inbound message
+// as handed over by the mailserver pipe.
+type Envelope struct {
+	From string
+	To   []string
+}
+
+// NewEnvelope builds an Envelope from the raw sender and a comma or
+// whitespace separated list of recipients.
+func NewEnvelope(from string, to string) Envelope {
+	from = trimAddress(from)
+	if strings.EqualFold(from, NullSender) {
+		from = ""
+	}
+
+	rcpts := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, rcpt := range strings.FieldsFunc(to, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}) {
+		rcpt = trimAddress(rcpt)
+		if rcpt == "" || !strings.Contains(rcpt, "@") {
+			continue
+		}
+
+		key := strings.ToLower(rcpt)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rcpts = append(rcpts, rcpt)
+	}
+
+	return Envelope{
+		From: from,
+		To:   rcpts,
+	}
+}
+
+// Recipients returns the addresses the message should be routed on.
+// Envelope recipients take precedence; header recipients are only used
+// when the mailserver did not pass any (e.g. legacy pipe invocations).
+func (e Envelope) Recipients(headerTo []string) []string {
+	if len(e.To) > 0 {
+		return e.To
+	}
+
+	return headerTo
+}
+
+func trimAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	addr = strings.TrimPrefix(addr, "<")
+	addr = strings.TrimSuffix(addr, ">")
+
+	return strings.TrimSpace(addr)
+}
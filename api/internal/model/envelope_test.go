@@ -0,0 +1,98 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewEnvelope(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want Envelope
+	}{
+		{
+			name: "single recipient",
+			from: "sender@example.com",
+			to:   "alias@mailx.net",
+			want: Envelope{From: "sender@example.com", To: []string{"alias@mailx.net"}},
+		},
+		{
+			name: "comma separated recipients",
+			from: "sender@example.com",
+			to:   "one@mailx.net,two@mailx.net",
+			want: Envelope{From: "sender@example.com", To: []string{"one@mailx.net", "two@mailx.net"}},
+		},
+		{
+			name: "space separated recipients with brackets",
+			from: "<sender@example.com>",
+			to:   "<one@mailx.net> two@mailx.net",
+			want: Envelope{From: "sender@example.com", To: []string{"one@mailx.net", "two@mailx.net"}},
+		},
+		{
+			name: "duplicate recipients",
+			from: "sender@example.com",
+			to:   "one@mailx.net, One@mailx.net",
+			want: Envelope{From: "sender@example.com", To: []string{"one@mailx.net"}},
+		},
+		{
+			name: "null sender",
+			from: "MAILER-DAEMON",
+			to:   "one@mailx.net",
+			want: Envelope{From: "", To: []string{"one@mailx.net"}},
+		},
+		{
+			name: "invalid recipients are skipped",
+			from: "",
+			to:   "invalid, ,one@mailx.net",
+			want: Envelope{From: "", To: []string{"one@mailx.net"}},
+		},
+		{
+			name: "empty",
+			from: "",
+			to:   "",
+			want: Envelope{From: "", To: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewEnvelope(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewEnvelope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvelopeRecipients(t *testing.T) {
+	tests := []struct {
+		name     string
+		envelope Envelope
+		headerTo []string
+		want     []string
+	}{
+		{
+			name:     "envelope recipients take precedence",
+			envelope: Envelope{To: []string{"bcc@mailx.net"}},
+			headerTo: []string{"someone@example.com"},
+			want:     []string{"bcc@mailx.net"},
+		},
+		{
+			name:     "fall back to header recipients",
+			envelope: Envelope{},
+			headerTo: []string{"alias@mailx.net"},
+			want:     []string{"alias@mailx.net"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.envelope.Recipients(tt.headerTo)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Recipients() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package model
+
+import (
+	"errors"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+type FilterMatch string
+
+const (
+	FilterMatchAll FilterMatch = "all"
+	FilterMatchAny FilterMatch = "any"
+)
+
+type FilterField string
+
+const (
+	FilterSubject        FilterField = "subject"
+	FilterHeader         FilterField = "header"
+	FilterBody           FilterField = "body"
+	FilterAttachmentSize FilterField = "attachment_size"
+	FilterAddress        FilterField = "address"
+	FilterEnvelope       FilterField = "envelope"
+	FilterSize           FilterField = "size"
+)
+
+type FilterAddressPart string
+
+const (
+	FilterPartAll       FilterAddressPart = "all"
+	FilterPartLocalpart FilterAddressPart = "localpart"
+	FilterPartDomain    FilterAddressPart = "domain"
+)
+
+type FilterOperator string
+
+const (
+	FilterIs       FilterOperator = "is"
+	FilterContains FilterOperator = "contains"
+	FilterMatches  FilterOperator = "matches"
+	FilterRegex    FilterOperator = "regex"
+	FilterExists   FilterOperator = "exists"
+	FilterOver     FilterOperator = "over"
+	FilterUnder    FilterOperator = "under"
+)
+
+type FilterActionType string
+
+const (
+	FilterDrop       FilterActionType = "drop"
+	FilterForwardTo  FilterActionType = "forward_to"
+	FilterTagSubject FilterActionType = "tag_subject"
+	FilterMarkSpam   FilterActionType = "mark_spam"
+)
+
+const maxFilterRegex = 1024
+
+// FilterCondition tests a single part of a message. Values of "is",
+// "contains" and "matches" (* and ? wildcards) are compared case-insensitively,
+// the condition matches if any of Values (or Value) matches.
+// Attachment sizes are in MB, message sizes in bytes with an optional K, M or
+// G suffix. Header is the header name for header and address conditions, and
+// "from" or "to" for envelope conditions.
+type FilterCondition struct {
+	Field    FilterField       `json:"field"`
+	Header   string            `json:"header,omitempty"`
+	Part     FilterAddressPart `json:"part,omitempty"`
+	Operator FilterOperator    `json:"operator"`
+	Value    string            `json:"value"`
+	Values   []string          `json:"values,omitempty"`
+	Negate   bool              `json:"negate"`
+}
+
+type FilterAction struct {
+	Type  FilterActionType `json:"type"`
+	Value string           `json:"value"`
+}
+
+// Filter is a server-side rule of an alias, or of the whole account when
+// AliasID is empty. Filters run in position order, alias filters first.
+type Filter struct {
+	BaseModel
+	UserID     string            `json:"-"`
+	AliasID    string            `gorm:"default:''" json:"alias_id"`
+	Name       string            `json:"name"`
+	Match      FilterMatch       `json:"match"`
+	Conditions []FilterCondition `gorm:"type:text;serializer:json" json:"conditions"`
+	Actions    []FilterAction    `gorm:"type:text;serializer:json" json:"actions"`
+	Stop       bool              `json:"stop"`
+	Enabled    bool              `json:"enabled"`
+	Position   int               `json:"position"`
+}
+
+// FilterResult is the outcome of all filters matching a message.
+type FilterResult struct {
+	Matched    []string `json:"matched"`
+	Drop       bool     `json:"drop"`
+	DropID     string   `json:"-"`
+	ForwardTo  string   `json:"forward_to"`
+	SubjectTag string   `json:"subject_tag"`
+	Spam       bool     `json:"spam"`
+}
+
+// Subject returns the subject with the result tag applied.
+func (r FilterResult) Subject(subject string) string {
+	if r.SubjectTag == "" || strings.HasPrefix(subject, r.SubjectTag+" ") {
+		return subject
+	}
+
+	return r.SubjectTag + " " + subject
+}
+
+// Validate checks the filter conditions and actions, including regex syntax.
+func (f Filter) Validate() error {
+	if f.Match != FilterMatchAll && f.Match != FilterMatchAny {
+		return ErrInvalidFilter
+	}
+
+	if len(f.Conditions) == 0 || len(f.Actions) == 0 {
+		return ErrInvalidFilter
+	}
+
+	for _, c := range f.Conditions {
+		switch c.Field {
+		case FilterSubject, FilterBody:
+			if c.Operator == FilterExists || c.Operator == FilterOver || c.Operator == FilterUnder {
+				return ErrInvalidFilter
+			}
+		case FilterHeader:
+			if strings.TrimSpace(c.Header) == "" || strings.ContainsAny(c.Header, ": \t\r\n") {
+				return ErrInvalidFilter
+			}
+			if c.Operator == FilterOver || c.Operator == FilterUnder {
+				return ErrInvalidFilter
+			}
+		case FilterAttachmentSize:
+			if c.Operator != FilterOver && c.Operator != FilterUnder {
+				return ErrInvalidFilter
+			}
+			if _, err := strconv.ParseFloat(c.Value, 64); err != nil {
+				return ErrInvalidFilter
+			}
+		case FilterSize:
+			if c.Operator != FilterOver && c.Operator != FilterUnder {
+				return ErrInvalidFilter
+			}
+			if _, err := ParseFilterSize(c.Value); err != nil {
+				return ErrInvalidFilter
+			}
+		case FilterAddress, FilterEnvelope:
+			if c.Field == FilterAddress && (strings.TrimSpace(c.Header) == "" || strings.ContainsAny(c.Header, ": \t\r\n")) {
+				return ErrInvalidFilter
+			}
+			if c.Field == FilterEnvelope && c.Header != "from" && c.Header != "to" {
+				return ErrInvalidFilter
+			}
+			if c.Part != "" && c.Part != FilterPartAll && c.Part != FilterPartLocalpart && c.Part != FilterPartDomain {
+				return ErrInvalidFilter
+			}
+			if c.Operator == FilterOver || c.Operator == FilterUnder {
+				return ErrInvalidFilter
+			}
+		default:
+			return ErrInvalidFilter
+		}
+
+		if c.Operator == FilterRegex {
+			for _, v := range c.keys() {
+				if len(v) > maxFilterRegex {
+					return ErrInvalidFilter
+				}
+				if _, err := regexp.Compile(v); err != nil {
+					return ErrInvalidFilter
+				}
+			}
+		}
+	}
+
+	for _, a := range f.Actions {
+		switch a.Type {
+		case FilterDrop, FilterMarkSpam:
+		case FilterForwardTo, FilterTagSubject:
+			if strings.TrimSpace(a.Value) == "" {
+				return ErrInvalidFilter
+			}
+		default:
+			return ErrInvalidFilter
+		}
+	}
+
+	return nil
+}
+
+// Test reports whether the message matches the filter conditions.
+func (f Filter) Test(msg Msg) bool {
+	if len(f.Conditions) == 0 {
+		return false
+	}
+
+	for _, c := range f.Conditions {
+		ok := c.Test(msg)
+		if f.Match == FilterMatchAny && ok {
+			return true
+		}
+		if f.Match != FilterMatchAny && !ok {
+			return false
+		}
+	}
+
+	return f.Match != FilterMatchAny
+}
+
+func (c FilterCondition) Test(msg Msg) bool {
+	return c.test(msg) != c.Negate
+}
+
+func (c FilterCondition) test(msg Msg) bool {
+	switch c.Field {
+	case FilterSubject:
+		return c.compare(msg.Subject)
+	case FilterBody:
+		return c.compare(msg.Body)
+	case FilterHeader:
+		values := msg.Headers[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(c.Header))]
+		if c.Operator == FilterExists {
+			return len(values) > 0
+		}
+		for _, v := range values {
+			if c.compare(strings.TrimSpace(v)) {
+				return true
+			}
+		}
+		return false
+	case FilterAddress:
+		if c.Operator == FilterExists {
+			return len(msg.Headers[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(c.Header))]) > 0
+		}
+		for _, v := range msg.Headers[textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(c.Header))] {
+			addresses, err := mail.ParseAddressList(v)
+			if err != nil {
+				continue
+			}
+			for _, address := range addresses {
+				if c.compare(addressPart(address.Address, c.Part)) {
+					return true
+				}
+			}
+		}
+		return false
+	case FilterEnvelope:
+		addresses := msg.Envelope.To
+		if c.Header == "from" {
+			addresses = []string{msg.Envelope.From}
+		}
+		for _, address := range addresses {
+			if c.compare(addressPart(address, c.Part)) {
+				return true
+			}
+		}
+		return false
+	case FilterSize:
+		size, err := ParseFilterSize(c.Value)
+		if err != nil {
+			return false
+		}
+		if c.Operator == FilterOver {
+			return msg.Size > size
+		}
+		return msg.Size < size
+	case FilterAttachmentSize:
+		mb, err := strconv.ParseFloat(c.Value, 64)
+		if err != nil {
+			return false
+		}
+		limit := int(mb * 1024 * 1024)
+		for _, size := range msg.Attachments {
+			if c.Operator == FilterOver && size > limit {
+				return true
+			}
+			if c.Operator == FilterUnder && size < limit {
+				return true
+			}
+		}
+		return false
+	}
+
+	return false
+}
+
+func (c FilterCondition) compare(s string) bool {
+	for _, key := range c.keys() {
+		if c.compareKey(s, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c FilterCondition) compareKey(s string, key string) bool {
+	switch c.Operator {
+	case FilterIs:
+		return strings.EqualFold(s, key)
+	case FilterContains:
+		return strings.Contains(strings.ToLower(s), strings.ToLower(key))
+	case FilterMatches:
+		return globMatch(key, s)
+	case FilterRegex:
+		re, err := regexp.Compile(key)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(s)
+	}
+
+	return false
+}
+
+func (c FilterCondition) keys() []string {
+	if len(c.Values) > 0 {
+		return c.Values
+	}
+
+	return []string{c.Value}
+}
+
+// ParseFilterSize parses a size in bytes with an optional K, M or G suffix.
+func ParseFilterSize(value string) (int, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1
+	switch {
+	case strings.HasSuffix(value, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(value, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(value, "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, ErrInvalidFilter
+	}
+
+	return n * multiplier, nil
+}
+
+func addressPart(address string, part FilterAddressPart) string {
+	local, domain, ok := strings.Cut(address, "@")
+	switch part {
+	case FilterPartLocalpart:
+		return local
+	case FilterPartDomain:
+		if !ok {
+			return ""
+		}
+		return domain
+	}
+
+	return address
+}
+
+// EvalFilters runs the enabled filters of the alias and the account against
+// the message. Filters are expected in evaluation order, see SortFilters.
+func EvalFilters(filters []Filter, aliasID string, msg Msg) FilterResult {
+	res := FilterResult{Matched: []string{}}
+
+	for _, f := range filters {
+		if !f.Enabled || (f.AliasID != "" && f.AliasID != aliasID) || !f.Test(msg) {
+			continue
+		}
+
+		res.Matched = append(res.Matched, f.ID)
+		for _, a := range f.Actions {
+			switch a.Type {
+			case FilterDrop:
+				res.Drop = true
+				res.DropID = f.ID
+			case FilterForwardTo:
+				if res.ForwardTo == "" {
+					res.ForwardTo = strings.ToLower(strings.TrimSpace(a.Value))
+				}
+			case FilterTagSubject:
+				if res.SubjectTag == "" {
+					res.SubjectTag = strings.TrimSpace(a.Value)
+				}
+			case FilterMarkSpam:
+				res.Spam = true
+			}
+		}
+
+		if res.Drop || f.Stop {
+			break
+		}
+	}
+
+	return res
+}
+
+// SortFilters orders filters for evaluation: alias filters before account
+// filters, then by position. Ties keep their original order.
+func SortFilters(filters []Filter) []Filter {
+	sorted := slices.Clone(filters)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].AliasID != "") != (sorted[j].AliasID != "") {
+			return sorted[i].AliasID != ""
+		}
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	return sorted
+}
+
+// globMatch matches s against a pattern with * and ? wildcards, ignoring case.
+func globMatch(pattern string, s string) bool {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, `.*`)
+	expr = strings.ReplaceAll(expr, `\?`, `.`)
+
+	re, err := regexp.Compile(`(?is)^` + expr + `$`)
+	if err != nil {
+		return false
+	}
+
+	return re.MatchString(s)
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  Filter
+		wantErr bool
+	}{
+		{
+			name: "subject regex",
+			filter: Filter{Match: FilterMatchAll,
+				Conditions: []FilterCondition{{Field: FilterSubject, Operator: FilterRegex, Value: `^\[list\]`}},
+				Actions:    []FilterAction{{Type: FilterTagSubject, Value: "[list]"}}},
+		},
+		{
+			name: "invalid regex",
+			filter: Filter{Match: FilterMatchAll,
+				Conditions: []FilterCondition{{Field: FilterSubject, Operator: FilterRegex, Value: `(`}},
+				Actions:    []FilterAction{{Type: FilterDrop}}},
+			wantErr: true,
+		},
+		{
+			name: "header without name",
+			filter: Filter{Match: FilterMatchAll,
+				Conditions: []FilterCondition{{Field: FilterHeader, Operator: FilterIs, Value: "x"}},
+				Actions:    []FilterAction{{Type: FilterDrop}}},
+			wantErr: true,
+		},
+		{
+			name: "attachment size not a number",
+			filter: Filter{Match: FilterMatchAll,
+				Conditions: []FilterCondition{{Field: FilterAttachmentSize, Operator: FilterOver, Value: "ten"}},
+				Actions:    []FilterAction{{Type: FilterDrop}}},
+			wantErr: true,
+		},
+		{
+			name: "forward without recipient",
+			filter: Filter{Match: FilterMatchAny,
+				Conditions: []FilterCondition{{Field: FilterBody, Operator: FilterContains, Value: "invoice"}},
+				Actions:    []FilterAction{{Type: FilterForwardTo}}},
+			wantErr: true,
+		},
+		{
+			name:    "no conditions",
+			filter:  Filter{Match: FilterMatchAll, Actions: []FilterAction{{Type: FilterDrop}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvalFilters(t *testing.T) {
+	msg := Msg{
+		From:        "news@example.com",
+		Subject:     "Weekly Newsletter #42",
+		Body:        "Hello",
+		Headers:     mail.Header{"List-Id": {"<weekly.example.com>"}},
+		Attachments: []int{3 * 1024 * 1024},
+	}
+
+	listID := FilterCondition{Field: FilterHeader, Header: "list-id", Operator: FilterIs, Value: "<weekly.example.com>"}
+	bigFile := FilterCondition{Field: FilterAttachmentSize, Operator: FilterOver, Value: "2"}
+	subject := FilterCondition{Field: FilterSubject, Operator: FilterRegex, Value: `#\d+$`}
+	other := FilterCondition{Field: FilterSubject, Operator: FilterMatches, Value: "invoice*"}
+
+	tests := []struct {
+		name    string
+		filters []Filter
+		aliasID string
+		want    FilterResult
+	}{
+		{
+			name: "tag and mark spam",
+			filters: []Filter{
+				{BaseModel: BaseModel{ID: "1"}, Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{listID, subject}, Actions: []FilterAction{{Type: FilterTagSubject, Value: "[news]"}}},
+				{BaseModel: BaseModel{ID: "2"}, Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{bigFile}, Actions: []FilterAction{{Type: FilterMarkSpam}}},
+			},
+			want: FilterResult{Matched: []string{"1", "2"}, SubjectTag: "[news]", Spam: true},
+		},
+		{
+			name: "drop stops evaluation",
+			filters: []Filter{
+				{BaseModel: BaseModel{ID: "1"}, Enabled: true, Match: FilterMatchAny, Conditions: []FilterCondition{other, bigFile}, Actions: []FilterAction{{Type: FilterDrop}}},
+				{BaseModel: BaseModel{ID: "2"}, Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{listID}, Actions: []FilterAction{{Type: FilterMarkSpam}}},
+			},
+			want: FilterResult{Matched: []string{"1"}, Drop: true, DropID: "1"},
+		},
+		{
+			name: "stop",
+			filters: []Filter{
+				{BaseModel: BaseModel{ID: "1"}, Enabled: true, Stop: true, Match: FilterMatchAll, Conditions: []FilterCondition{listID}, Actions: []FilterAction{{Type: FilterForwardTo, Value: "Me@example.net"}}},
+				{BaseModel: BaseModel{ID: "2"}, Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{listID}, Actions: []FilterAction{{Type: FilterMarkSpam}}},
+			},
+			want: FilterResult{Matched: []string{"1"}, ForwardTo: "me@example.net"},
+		},
+		{
+			name: "negate, disabled and other alias",
+			filters: []Filter{
+				{BaseModel: BaseModel{ID: "1"}, Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{{Field: FilterHeader, Header: "List-Id", Operator: FilterExists, Negate: true}}, Actions: []FilterAction{{Type: FilterDrop}}},
+				{BaseModel: BaseModel{ID: "2"}, Enabled: false, Match: FilterMatchAll, Conditions: []FilterCondition{listID}, Actions: []FilterAction{{Type: FilterDrop}}},
+				{BaseModel: BaseModel{ID: "3"}, AliasID: "alias-2", Enabled: true, Match: FilterMatchAll, Conditions: []FilterCondition{listID}, Actions: []FilterAction{{Type: FilterDrop}}},
+			},
+			aliasID: "alias-1",
+			want:    FilterResult{Matched: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvalFilters(tt.filters, tt.aliasID, msg)
+			if strings.Join(got.Matched, ",") != strings.Join(tt.want.Matched, ",") || got.Drop != tt.want.Drop || got.DropID != tt.want.DropID ||
+				got.ForwardTo != tt.want.ForwardTo || got.SubjectTag != tt.want.SubjectTag || got.Spam != tt.want.Spam {
+				t.Errorf("EvalFilters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortFilters(t *testing.T) {
+	filters := []Filter{
+		{BaseModel: BaseModel{ID: "account-2"}, Position: 2},
+		{BaseModel: BaseModel{ID: "alias-1"}, AliasID: "a", Position: 1},
+		{BaseModel: BaseModel{ID: "account-1"}, Position: 1},
+		{BaseModel: BaseModel{ID: "alias-0"}, AliasID: "a", Position: 0},
+	}
+
+	got := []string{}
+	for _, f := range SortFilters(filters) {
+		got = append(got, f.ID)
+	}
+
+	want := "alias-0,alias-1,account-1,account-2"
+	if strings.Join(got, ",") != want {
+		t.Errorf("SortFilters() = %v, want %v", got, want)
+	}
+}
+
+func TestFilterResultSubject(t *testing.T) {
+	res := FilterResult{SubjectTag: "[news]"}
+	if got := res.Subject("Hello"); got != "[news] Hello" {
+		t.Errorf("Subject() = %q", got)
+	}
+	if got := res.Subject("[news] Hello"); got != "[news] Hello" {
+		t.Errorf("Subject() tagged twice = %q", got)
+	}
+}
+
+func TestParseMsgAttachments(t *testing.T) {
+	data := "From: sender@example.com\r\n" +
+		"To: alias@example.net\r\n" +
+		"Subject: Files\r\n" +
+		"Content-Type: multipart/mixed; boundary=\"b1\"\r\n\r\n" +
+		"--b1\r\nContent-Type: text/plain\r\n\r\nHello\r\n" +
+		"--b1\r\nContent-Type: application/octet-stream\r\nContent-Disposition: attachment; filename=\"a.bin\"\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		"AAECAwQFBgcI\r\nCQ==\r\n" +
+		"--b1\r\nContent-Type: text/plain; name=\"b.txt\"\r\n\r\n12345\r\n" +
+		"--b1--\r\n"
+
+	msg, err := ParseMsg([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseMsg() error = %v", err)
+	}
+
+	if len(msg.Attachments) != 2 || msg.Attachments[0] != 10 || msg.Attachments[1] != 5 {
+		t.Errorf("Attachments = %v, want [10 5]", msg.Attachments)
+	}
+
+	if msg.Headers.Get("Subject") != "Files" {
+		t.Errorf("Headers Subject = %q", msg.Headers.Get("Subject"))
+	}
+}
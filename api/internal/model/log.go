@@ -12,6 +12,11 @@ const (
 	DisabledDomain       LogType = "disabled_domain"
 	UnauthorisedSend     LogType = "unauthorised_send"
 	InactiveSubscription LogType = "inactive_subscription"
+	BlockedSender        LogType = "blocked_sender"
+	FilteredMessage      LogType = "filtered_message"
+	SpamMessage          LogType = "spam"
+	SendReplyLimit       LogType = "send_reply_limit"
+	InboundRateLimit     LogType = "inbound_rate_limit"
 )
 
 type Log struct {
@@ -14,14 +14,26 @@ const (
 	Reply      MessageType = 2
 	Send       MessageType = 3
 	FailBounce MessageType = 4
+	Duplicate  MessageType = 5
+	Spam       MessageType = 6
 )
 
 type Message struct {
 	ID        uint        `json:"-" gorm:"primaryKey"`
-	CreatedAt time.Time   `json:"created_at"`
-	UserID    string      `json:"-"`
-	AliasID   string      `json:"-"`
+	CreatedAt time.Time   `json:"created_at" gorm:"index:idx_messages_user_created,priority:2;index:idx_messages_alias_created,priority:2"`
+	UserID    string      `json:"-" gorm:"index:idx_messages_user_created,priority:1"`
+	AliasID   string      `json:"-" gorm:"index:idx_messages_alias_created,priority:1"`
 	Type      MessageType `json:"type"`
+	RuleID    string      `json:"-" gorm:"default:''"` // sender rule of a blocked message
+	FilterID  string      `json:"-" gorm:"default:''"` // filter of a dropped message
+}
+
+// MessageCount is the number of messages of a type within an hour,
+// Time being the earliest message of the group.
+type MessageCount struct {
+	Time  time.Time
+	Type  MessageType
+	Count int
 }
 
 func ParseReplyTo(email string) (string, string) {
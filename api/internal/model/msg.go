@@ -2,6 +2,7 @@ package model
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
@@ -20,12 +21,16 @@ var (
 )
 
 type Msg struct {
-	From     string
-	FromName string
-	To       []string
-	Subject  string
-	Body     string
-	Type     MessageType
+	From        string
+	FromName    string
+	To          []string
+	Subject     string
+	Body        string
+	Type        MessageType
+	Headers     mail.Header
+	Attachments []int // decoded attachment sizes in bytes
+	Size        int
+	Envelope    Envelope
 }
 
 func ParseMsg(data []byte) (Msg, error) {
@@ -43,13 +48,16 @@ func ParseMsg(data []byte) (Msg, error) {
 
 	subject := utils.DecodeHeaderWithCharset(msg.Header.Get("Subject"))
 
+	// To header is optional, routing is done on envelope recipients (e.g. Bcc)
 	to := make([]string, 0)
-	addresses, err := mail.ParseAddressList(utils.NormalizeAddressSeparators(utils.DecodeHeaderWithCharset(msg.Header.Get("To"))))
-	if err != nil {
-		return Msg{}, fmt.Errorf("error parsing To header: %w", err)
-	}
-	for _, address := range addresses {
-		to = append(to, address.Address)
+	if toHeader := strings.TrimSpace(msg.Header.Get("To")); toHeader != "" {
+		addresses, err := mail.ParseAddressList(utils.NormalizeAddressSeparators(utils.DecodeHeaderWithCharset(toHeader)))
+		if err != nil {
+			return Msg{}, fmt.Errorf("error parsing To header: %w", err)
+		}
+		for _, address := range addresses {
+			to = append(to, address.Address)
+		}
 	}
 
 	from, err := mail.ParseAddress(utils.DecodeHeaderWithCharset(msg.Header.Get("From")))
@@ -80,15 +88,97 @@ func ParseMsg(data []byte) (Msg, error) {
 	}
 
 	return Msg{
-		From:     fromAddress,
-		FromName: from.Name,
-		To:       to,
-		Subject:  subject,
-		Body:     body,
-		Type:     msgType,
+		From:        fromAddress,
+		FromName:    from.Name,
+		To:          to,
+		Subject:     subject,
+		Body:        body,
+		Type:        msgType,
+		Headers:     msg.Header,
+		Attachments: attachmentSizes(msg.Header.Get("Content-Type"), buf.Bytes()),
+		Size:        len(data),
 	}, nil
 }
 
+// ParseMessageID returns the Message-ID header of the given email, or an
+// empty string if it is missing or the email cannot be parsed.
+func ParseMessageID(data []byte) string {
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(msg.Header.Get("Message-ID"))
+}
+
+// attachmentSizes walks the MIME tree and returns the decoded size of every
+// attachment. Nested messages (message/rfc822) count as a single attachment.
+func attachmentSizes(contentType string, body []byte) []int {
+	sizes := []int{}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return sizes
+	}
+
+	mr := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			return sizes
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return sizes
+		}
+
+		partType := part.Header.Get("Content-Type")
+		if strings.HasPrefix(strings.ToLower(partType), "multipart/") {
+			sizes = append(sizes, attachmentSizes(partType, data)...)
+			continue
+		}
+
+		disposition, dparams, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition"))
+		_, tparams, _ := mime.ParseMediaType(partType)
+		if disposition != "attachment" && dparams["filename"] == "" && tparams["name"] == "" {
+			continue
+		}
+
+		// multipart.Reader decodes quoted-printable, base64 is left to us
+		if strings.EqualFold(strings.TrimSpace(part.Header.Get("Content-Transfer-Encoding")), "base64") {
+			n, err := io.Copy(io.Discard, base64.NewDecoder(base64.StdEncoding, newlineStripper{bytes.NewReader(data)}))
+			if err == nil {
+				sizes = append(sizes, int(n))
+				continue
+			}
+		}
+
+		sizes = append(sizes, len(data))
+	}
+}
+
+// newlineStripper drops CR and LF so base64 bodies can be decoded.
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n newlineStripper) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		k := 0
+		for _, b := range p[:c] {
+			if b != '\r' && b != '\n' {
+				p[k] = b
+				k++
+			}
+		}
+		if k > 0 || err != nil {
+			return k, err
+		}
+	}
+}
+
 // isReply checks whether the given email is a reply.
 func isReply(m *mail.Message) bool {
 	if m.Header.Get("In-Reply-To") != "" || m.Header.Get("References") != "" {
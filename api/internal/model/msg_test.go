@@ -430,6 +430,18 @@ func TestParseMsg(t *testing.T) {
 				Body:     "Body",
 				Type:     Send,
 			}},
+		{
+			// Bcc delivery: no To header, recipients come from the SMTP envelope.
+			name: "missing To header",
+			data: "From: sender@example.com\r\nSubject: Test\r\n\r\nBody",
+			want: Msg{
+				From:     "sender@example.com",
+				FromName: "",
+				To:       []string{},
+				Subject:  "Test",
+				Body:     "Body",
+				Type:     Send,
+			}},
 		{
 			// Regression: Cyrillic Subject encoded as UTF-8 QP (well-formed RFC 2047).
 			// ParseMsg must decode it to plain Unicode, not leave the encoded-word as-is.
@@ -497,3 +509,35 @@ func compareMessages(a, b Msg) bool {
 	}
 	return true
 }
+
+func TestParseMessageID(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "Message-ID present",
+			data: "From: sender@example.com\r\nMessage-ID:  <abc@example.com> \r\nSubject: Test\r\n\r\nBody",
+			want: "<abc@example.com>",
+		},
+		{
+			name: "Message-ID missing",
+			data: "From: sender@example.com\r\nSubject: Test\r\n\r\nBody",
+			want: "",
+		},
+		{
+			name: "invalid message",
+			data: "invalid",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMessageID([]byte(tt.data)); got != tt.want {
+				t.Errorf("ParseMessageID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+type QuarantineReason string
+
+const (
+	QuarantineDisabledAlias QuarantineReason = "disabled_alias"
+	QuarantineBlockedSender QuarantineReason = "blocked_sender"
+	QuarantineFailedAuth    QuarantineReason = "failed_auth"
+)
+
+// Quarantine is a blocked message kept for the user to release or delete. The
+// raw message is stored encrypted in a file named after the ID.
+type Quarantine struct {
+	BaseModel
+	UserID    string           `gorm:"index" json:"-"`
+	AliasID   string           `gorm:"index" json:"alias_id"`
+	AliasName string           `json:"alias_name"`
+	From      string           `json:"from"`
+	FromName  string           `json:"from_name"`
+	Subject   string           `json:"subject"`
+	Reason    QuarantineReason `gorm:"size:32" json:"reason"`
+	Size      int              `json:"size"`
+	ExpiresAt time.Time        `gorm:"index" json:"expires_at"`
+}
+
+// QuarantineUsage is the number and total size of the quarantined messages
+// of a user.
+type QuarantineUsage struct {
+	Count int
+	Size  int
+}
+
+// QuarantinePreview is a quarantined message with its header fields.
+type QuarantinePreview struct {
+	Quarantine
+	Headers map[string][]string `json:"headers"`
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidRulePattern = errors.New("invalid sender pattern")
+)
+
+type RuleAction string
+
+const (
+	RuleBlock RuleAction = "block"
+	RuleAllow RuleAction = "allow"
+)
+
+// Rule is a sender rule of an alias, or of the whole account when AliasID is
+// empty. Pattern is an exact address (john@example.com), a domain
+// (example.com or @example.com) or a wildcard (*@*.example.com, news*@example.com).
+type Rule struct {
+	BaseModel
+	UserID      string     `json:"-"`
+	AliasID     string     `gorm:"default:''" json:"alias_id"`
+	Pattern     string     `json:"pattern"`
+	Action      RuleAction `json:"action"`
+	Description string     `gorm:"default:''" json:"description"`
+}
+
+// NormalizeRulePattern lowercases and validates a sender pattern.
+func NormalizeRulePattern(pattern string) (string, error) {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "@")
+
+	if pattern == "" || len(pattern) > 255 || strings.ContainsAny(pattern, " \t\r\n<>,;\"") {
+		return "", ErrInvalidRulePattern
+	}
+
+	if strings.Count(pattern, "@") > 1 {
+		return "", ErrInvalidRulePattern
+	}
+
+	local, domain, ok := strings.Cut(pattern, "@")
+	if ok && (local == "" || domain == "") {
+		return "", ErrInvalidRulePattern
+	}
+
+	if pattern == "*" || pattern == "*@*" {
+		return "", ErrInvalidRulePattern
+	}
+
+	return pattern, nil
+}
+
+// Match reports whether the sender address matches the rule pattern.
+// Patterns without "@" are matched against the sender domain.
+func (r Rule) Match(sender string) bool {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	pattern := strings.ToLower(r.Pattern)
+	if sender == "" || pattern == "" {
+		return false
+	}
+
+	if !strings.Contains(pattern, "@") {
+		_, domain, ok := strings.Cut(sender, "@")
+		if !ok {
+			return false
+		}
+		return wildcardMatch(pattern, domain)
+	}
+
+	return wildcardMatch(pattern, sender)
+}
+
+// MatchRules returns the rule deciding the fate of the sender, if any.
+// Alias rules take precedence over account rules, and within the same scope
+// an allow rule takes precedence over a block rule.
+func MatchRules(rules []Rule, aliasID string, sender string) (Rule, bool) {
+	scopes := []string{aliasID, ""}
+	if aliasID == "" {
+		scopes = []string{""}
+	}
+
+	for _, scope := range scopes {
+		var block *Rule
+		for i := range rules {
+			rule := rules[i]
+			if rule.AliasID != scope || !rule.Match(sender) {
+				continue
+			}
+
+			if rule.Action == RuleAllow {
+				return rule, true
+			}
+
+			if rule.Action == RuleBlock && block == nil {
+				block = &rules[i]
+			}
+		}
+
+		if block != nil {
+			return *block, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// wildcardMatch matches s against a pattern where "*" matches any sequence.
+func wildcardMatch(pattern string, s string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == s
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+
+	return strings.HasSuffix(s, last)
+}
@@ -0,0 +1,98 @@
+package model
+
+import "testing"
+
+func TestNormalizeRulePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		wantErr bool
+	}{
+		{"John@Example.com", "john@example.com", false},
+		{"@example.com", "example.com", false},
+		{" example.com ", "example.com", false},
+		{"*@*.example.com", "*@*.example.com", false},
+		{"news*@example.com", "news*@example.com", false},
+		{"", "", true},
+		{"*", "", true},
+		{"*@*", "", true},
+		{"john@", "", true},
+		{"a@b@example.com", "", true},
+		{"john doe@example.com", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeRulePattern(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NormalizeRulePattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("NormalizeRulePattern(%q) = %q, want %q", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		sender  string
+		want    bool
+	}{
+		{"john@example.com", "john@example.com", true},
+		{"john@example.com", "JOHN@Example.com", true},
+		{"john@example.com", "jane@example.com", false},
+		{"example.com", "john@example.com", true},
+		{"example.com", "john@sub.example.com", false},
+		{"*.example.com", "john@sub.example.com", true},
+		{"*.example.com", "john@example.com", false},
+		{"*@*.example.com", "john@mail.example.com", true},
+		{"news*@example.com", "newsletter@example.com", true},
+		{"news*@example.com", "john@example.com", false},
+		{"*bot*@example.com", "mybot1@example.com", true},
+		{"example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		got := Rule{Pattern: tt.pattern}.Match(tt.sender)
+		if got != tt.want {
+			t.Errorf("Rule{%q}.Match(%q) = %v, want %v", tt.pattern, tt.sender, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRules(t *testing.T) {
+	rules := []Rule{
+		{BaseModel: BaseModel{ID: "1"}, Pattern: "example.com", Action: RuleBlock},
+		{BaseModel: BaseModel{ID: "2"}, Pattern: "boss@example.com", Action: RuleAllow},
+		{BaseModel: BaseModel{ID: "3"}, AliasID: "alias-1", Pattern: "spam.com", Action: RuleBlock},
+		{BaseModel: BaseModel{ID: "4"}, AliasID: "alias-1", Pattern: "*@example.com", Action: RuleBlock},
+		{BaseModel: BaseModel{ID: "5"}, AliasID: "alias-2", Pattern: "spam.com", Action: RuleAllow},
+	}
+
+	tests := []struct {
+		name    string
+		aliasID string
+		sender  string
+		wantID  string
+		wantOK  bool
+	}{
+		{"account block", "", "john@example.com", "1", true},
+		{"account allow beats block", "", "boss@example.com", "2", true},
+		{"no match", "", "john@other.com", "", false},
+		{"alias block", "alias-1", "john@spam.com", "3", true},
+		{"alias rule before account allow", "alias-1", "boss@example.com", "4", true},
+		{"alias allow", "alias-2", "john@spam.com", "5", true},
+		{"account rule for alias", "alias-2", "john@example.com", "1", true},
+		{"other alias rule ignored", "alias-3", "john@spam.com", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := MatchRules(rules, tt.aliasID, tt.sender)
+			if ok != tt.wantOK || rule.ID != tt.wantID {
+				t.Errorf("MatchRules() = (%q, %v), want (%q, %v)", rule.ID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
@@ -9,13 +9,22 @@ import (
 	"github.com/go-webauthn/webauthn/webauthn"
 )
 
+// Session is a signed in session of a user, or the WebAuthn ceremony of a
+// registration or login in progress. Signed in sessions keep the IP and user
+// agent they were last seen with.
 type Session struct {
 	BaseModel
 	UserID      string               `json:"-"`
-	Token       string               `gorm:"unique" json:"token"`
+	Token       string               `gorm:"unique" json:"-"`
 	Data        []byte               `gorm:"type:blob" json:"-"`
 	SessionData webauthn.SessionData `gorm:"-" json:"-"`
 	ExpiresAt   time.Time            `json:"expires_at"`
+	AccessKeyID string               `gorm:"size:36;index" json:"access_key_id"`
+	SignedIn    bool                 `json:"-"`
+	IP          string               `gorm:"size:45" json:"ip"`
+	UserAgent   string               `gorm:"size:255" json:"user_agent"`
+	LastSeenAt  *time.Time           `json:"last_seen_at"`
+	Current     bool                 `gorm:"-" json:"current"`
 }
 
 func GenSessionToken() (string, error) {
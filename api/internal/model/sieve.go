@@ -0,0 +1,803 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SieveError reports a construct of a Sieve script that could not be imported.
+type SieveError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// SieveRule is a filter compiled from the if/elsif/else branch at Line.
+type SieveRule struct {
+	Line   int
+	Filter Filter
+}
+
+// SieveImport is the result of importing a Sieve script.
+type SieveImport struct {
+	Imported int          `json:"imported"`
+	Errors   []SieveError `json:"errors"`
+}
+
+// ParseSieve compiles the supported subset of a Sieve script (RFC 5228) into
+// filters. Supported tests are header, address, envelope, exists, size, allof,
+// anyof, not, true and false; supported actions are keep, discard, redirect,
+// fileinto, addflag/setflag and stop. Unsupported commands and tests are
+// skipped and reported by line, a syntax error stops the import.
+func ParseSieve(script string) ([]SieveRule, []SieveError) {
+	lex := &sieveLexer{src: []rune(script), line: 1}
+	tokens, err := lex.tokens()
+	if err != nil {
+		return nil, []SieveError{*err}
+	}
+
+	p := &sieveParser{tokens: tokens}
+	commands, err := p.commands(false)
+	if err != nil {
+		return nil, []SieveError{*err}
+	}
+
+	c := &sieveCompiler{errors: []SieveError{}}
+	c.compile(commands)
+
+	return c.rules, c.errors
+}
+
+// Lexer
+
+type sieveTokenKind int
+
+const (
+	sieveEOF sieveTokenKind = iota
+	sieveIdent
+	sieveTag
+	sieveString
+	sieveNumber
+	sievePunct
+)
+
+type sieveToken struct {
+	kind  sieveTokenKind
+	value string
+	line  int
+}
+
+type sieveLexer struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (l *sieveLexer) tokens() ([]sieveToken, *SieveError) {
+	tokens := []sieveToken{}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == sieveEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *sieveLexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *sieveLexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+	}
+	return r
+}
+
+// skip skips white space and comments.
+func (l *sieveLexer) skip() *SieveError {
+	for l.pos < len(l.src) {
+		r := l.peek(0)
+		switch {
+		case unicode.IsSpace(r):
+			l.advance()
+		case r == '#':
+			for l.pos < len(l.src) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		case r == '/' && l.peek(1) == '*':
+			line := l.line
+			l.advance()
+			l.advance()
+			for l.pos < len(l.src) && !(l.peek(0) == '*' && l.peek(1) == '/') {
+				l.advance()
+			}
+			if l.pos >= len(l.src) {
+				return &SieveError{Line: line, Message: "unterminated comment"}
+			}
+			l.advance()
+			l.advance()
+		default:
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (l *sieveLexer) next() (sieveToken, *SieveError) {
+	if err := l.skip(); err != nil {
+		return sieveToken{}, err
+	}
+
+	line := l.line
+	if l.pos >= len(l.src) {
+		return sieveToken{kind: sieveEOF, line: line}, nil
+	}
+
+	r := l.peek(0)
+	switch {
+	case r == '"':
+		l.advance()
+		var b strings.Builder
+		for {
+			if l.pos >= len(l.src) {
+				return sieveToken{}, &SieveError{Line: line, Message: "unterminated string"}
+			}
+			c := l.advance()
+			if c == '"' {
+				break
+			}
+			if c == '\\' && l.pos < len(l.src) {
+				c = l.advance()
+			}
+			b.WriteRune(c)
+		}
+		return sieveToken{kind: sieveString, value: b.String(), line: line}, nil
+	case r == ':':
+		l.advance()
+		ident := l.ident()
+		if ident == "" {
+			return sieveToken{}, &SieveError{Line: line, Message: "invalid tag"}
+		}
+		return sieveToken{kind: sieveTag, value: strings.ToLower(ident), line: line}, nil
+	case unicode.IsDigit(r):
+		var b strings.Builder
+		for l.pos < len(l.src) && unicode.IsDigit(l.peek(0)) {
+			b.WriteRune(l.advance())
+		}
+		if q := unicode.ToUpper(l.peek(0)); q == 'K' || q == 'M' || q == 'G' {
+			l.advance()
+			b.WriteRune(q)
+		}
+		return sieveToken{kind: sieveNumber, value: b.String(), line: line}, nil
+	case unicode.IsLetter(r) || r == '_':
+		ident := strings.ToLower(l.ident())
+		if ident == "text" && l.peek(0) == ':' {
+			l.advance()
+			return l.multiline(line)
+		}
+		return sieveToken{kind: sieveIdent, value: ident, line: line}, nil
+	case strings.ContainsRune("[](),;{}", r):
+		l.advance()
+		return sieveToken{kind: sievePunct, value: string(r), line: line}, nil
+	}
+
+	return sieveToken{}, &SieveError{Line: line, Message: fmt.Sprintf("unexpected character %q", r)}
+}
+
+func (l *sieveLexer) ident() string {
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		r := l.peek(0)
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			break
+		}
+		b.WriteRune(l.advance())
+	}
+	return b.String()
+}
+
+// multiline reads a text: string, terminated by a line with a single dot.
+func (l *sieveLexer) multiline(line int) (sieveToken, *SieveError) {
+	for l.pos < len(l.src) && l.peek(0) != '\n' {
+		l.advance()
+	}
+	if l.pos < len(l.src) {
+		l.advance()
+	}
+
+	lines := []string{}
+	for l.pos < len(l.src) {
+		var b strings.Builder
+		for l.pos < len(l.src) && l.peek(0) != '\n' {
+			b.WriteRune(l.advance())
+		}
+		if l.pos < len(l.src) {
+			l.advance()
+		}
+
+		text := strings.TrimRight(b.String(), "\r")
+		if text == "." {
+			return sieveToken{kind: sieveString, value: strings.Join(lines, "\n"), line: line}, nil
+		}
+		lines = append(lines, strings.TrimPrefix(text, "."))
+	}
+
+	return sieveToken{}, &SieveError{Line: line, Message: "unterminated multi-line string"}
+}
+
+// Parser
+
+type sieveArg struct {
+	tag     string
+	strings []string
+	number  string
+	isList  bool
+}
+
+type sieveTest struct {
+	name  string
+	line  int
+	args  []sieveArg
+	tests []sieveTest
+}
+
+type sieveCommand struct {
+	name     string
+	line     int
+	args     []sieveArg
+	tests    []sieveTest
+	block    []sieveCommand
+	hasBlock bool
+}
+
+type sieveParser struct {
+	tokens []sieveToken
+	pos    int
+}
+
+func (p *sieveParser) peek() sieveToken {
+	return p.tokens[p.pos]
+}
+
+func (p *sieveParser) next() sieveToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != sieveEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *sieveParser) isPunct(value string) bool {
+	tok := p.peek()
+	return tok.kind == sievePunct && tok.value == value
+}
+
+func (p *sieveParser) expect(value string) *SieveError {
+	tok := p.next()
+	if tok.kind != sievePunct || tok.value != value {
+		return &SieveError{Line: tok.line, Message: fmt.Sprintf("expected %q", value)}
+	}
+	return nil
+}
+
+func (p *sieveParser) commands(inBlock bool) ([]sieveCommand, *SieveError) {
+	commands := []sieveCommand{}
+	for {
+		tok := p.peek()
+		if tok.kind == sieveEOF {
+			if inBlock {
+				return nil, &SieveError{Line: tok.line, Message: "missing \"}\""}
+			}
+			return commands, nil
+		}
+		if inBlock && p.isPunct("}") {
+			p.next()
+			return commands, nil
+		}
+		if tok.kind != sieveIdent {
+			return nil, &SieveError{Line: tok.line, Message: "expected a command"}
+		}
+		p.next()
+
+		cmd := sieveCommand{name: tok.value, line: tok.line}
+		var err *SieveError
+		cmd.args, cmd.tests, err = p.arguments()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.isPunct("{") {
+			p.next()
+			cmd.hasBlock = true
+			cmd.block, err = p.commands(true)
+			if err != nil {
+				return nil, err
+			}
+		} else if err := p.expect(";"); err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, cmd)
+	}
+}
+
+func (p *sieveParser) arguments() ([]sieveArg, []sieveTest, *SieveError) {
+	args := []sieveArg{}
+args:
+	for {
+		tok := p.peek()
+		switch {
+		case tok.kind == sieveTag:
+			p.next()
+			args = append(args, sieveArg{tag: tok.value})
+		case tok.kind == sieveNumber:
+			p.next()
+			args = append(args, sieveArg{number: tok.value})
+		case tok.kind == sieveString:
+			p.next()
+			args = append(args, sieveArg{strings: []string{tok.value}})
+		case p.isPunct("["):
+			p.next()
+			list := sieveArg{isList: true}
+			for {
+				s := p.next()
+				if s.kind != sieveString {
+					return nil, nil, &SieveError{Line: s.line, Message: "expected a string in list"}
+				}
+				list.strings = append(list.strings, s.value)
+				if p.isPunct(",") {
+					p.next()
+					continue
+				}
+				if err := p.expect("]"); err != nil {
+					return nil, nil, err
+				}
+				break
+			}
+			args = append(args, list)
+		default:
+			break args
+		}
+	}
+
+	if p.peek().kind == sieveIdent {
+		test, err := p.test()
+		if err != nil {
+			return nil, nil, err
+		}
+		return args, []sieveTest{test}, nil
+	}
+
+	if p.isPunct("(") {
+		p.next()
+		tests := []sieveTest{}
+		for {
+			test, err := p.test()
+			if err != nil {
+				return nil, nil, err
+			}
+			tests = append(tests, test)
+			if p.isPunct(",") {
+				p.next()
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, nil, err
+			}
+			return args, tests, nil
+		}
+	}
+
+	return args, nil, nil
+}
+
+func (p *sieveParser) test() (sieveTest, *SieveError) {
+	tok := p.next()
+	if tok.kind != sieveIdent {
+		return sieveTest{}, &SieveError{Line: tok.line, Message: "expected a test"}
+	}
+
+	args, tests, err := p.arguments()
+	if err != nil {
+		return sieveTest{}, err
+	}
+
+	return sieveTest{name: tok.value, line: tok.line, args: args, tests: tests}, nil
+}
+
+// Compiler
+
+// sieveGroup is a list of conditions combined with all or any.
+type sieveGroup struct {
+	match FilterMatch
+	conds []FilterCondition
+}
+
+// not negates a group using De Morgan's laws.
+func (g sieveGroup) not() sieveGroup {
+	neg := sieveGroup{match: FilterMatchAny, conds: make([]FilterCondition, 0, len(g.conds))}
+	if g.match == FilterMatchAny {
+		neg.match = FilterMatchAll
+	}
+	for _, c := range g.conds {
+		c.Negate = !c.Negate
+		neg.conds = append(neg.conds, c)
+	}
+	return neg
+}
+
+// merge combines groups with the given match, flattening groups that use the
+// same match. Groups of the other kind can't be expressed as a single filter.
+func merge(match FilterMatch, groups []sieveGroup) (sieveGroup, bool) {
+	merged := sieveGroup{match: match}
+	for _, g := range groups {
+		if len(g.conds) > 1 && g.match != match {
+			return sieveGroup{}, false
+		}
+		merged.conds = append(merged.conds, g.conds...)
+	}
+	return merged, true
+}
+
+// sieveTrue is a condition matching every message.
+func sieveTrue() FilterCondition {
+	return FilterCondition{Field: FilterSize, Operator: FilterUnder, Value: "0", Negate: true}
+}
+
+type sieveCompiler struct {
+	rules  []SieveRule
+	errors []SieveError
+}
+
+func (c *sieveCompiler) fail(line int, format string, args ...any) {
+	c.errors = append(c.errors, SieveError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *sieveCompiler) compile(commands []sieveCommand) {
+	// Negated conditions of the previous branches of an if/elsif chain
+	var chain []sieveGroup
+	chainValid := false
+
+	for _, cmd := range commands {
+		switch cmd.name {
+		case "require":
+			continue
+		case "if", "elsif", "else":
+			if cmd.name != "if" && !chainValid {
+				c.fail(cmd.line, "%s without a preceding if", cmd.name)
+				continue
+			}
+			if cmd.name == "if" {
+				chain = nil
+			}
+			if !cmd.hasBlock {
+				c.fail(cmd.line, "%s requires a block", cmd.name)
+				chainValid = false
+				continue
+			}
+
+			group := sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}}
+			never := false
+			if cmd.name != "else" {
+				if len(cmd.tests) != 1 {
+					c.fail(cmd.line, "%s requires a single test", cmd.name)
+					chainValid = false
+					continue
+				}
+				var ok bool
+				group, never, ok = c.test(cmd.tests[0])
+				if !ok {
+					chainValid = false
+					continue
+				}
+			}
+
+			conds := group
+			if len(chain) > 0 {
+				groups := append(append([]sieveGroup{}, chain...), group)
+				if cmd.name == "else" {
+					groups = chain
+				}
+				merged, ok := merge(FilterMatchAll, groups)
+				if !ok {
+					c.fail(cmd.line, "%s can't be combined with the conditions of the previous branches", cmd.name)
+					chainValid = false
+					continue
+				}
+				conds = merged
+			}
+
+			if !never {
+				c.block(cmd, conds)
+			}
+
+			// The negation of a test that never matches always holds
+			if !never {
+				chain = append(chain, group.not())
+			}
+			chainValid = cmd.name != "else"
+		case "keep":
+			chainValid = false
+		case "stop":
+			// Nothing after an unconditional stop is ever executed
+			return
+		case "discard", "redirect", "fileinto", "addflag", "setflag":
+			chainValid = false
+			c.block(sieveCommand{name: "if", line: cmd.line, block: []sieveCommand{cmd}},
+				sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}})
+		default:
+			chainValid = false
+			c.fail(cmd.line, "command %q is not supported", cmd.name)
+		}
+	}
+}
+
+// block compiles the actions of a branch into a filter.
+func (c *sieveCompiler) block(cmd sieveCommand, group sieveGroup) {
+	filter := Filter{
+		Name:       fmt.Sprintf("Sieve line %d", cmd.line),
+		Match:      group.match,
+		Conditions: group.conds,
+		Actions:    []FilterAction{},
+		Enabled:    true,
+	}
+	if len(group.conds) == 1 {
+		filter.Match = FilterMatchAll
+	}
+
+	for _, action := range cmd.block {
+		switch action.name {
+		case "keep":
+		case "stop":
+			filter.Stop = true
+		case "discard":
+			filter.Actions = append(filter.Actions, FilterAction{Type: FilterDrop})
+		case "redirect":
+			addr, ok := c.lastString(action)
+			if !ok {
+				continue
+			}
+			filter.Actions = append(filter.Actions, FilterAction{Type: FilterForwardTo, Value: addr})
+		case "fileinto":
+			folder, ok := c.lastString(action)
+			if !ok {
+				continue
+			}
+			switch strings.ToLower(folder) {
+			case "inbox":
+			case "junk", "spam", "bulk", "junk e-mail", "junk email":
+				filter.Actions = append(filter.Actions, FilterAction{Type: FilterMarkSpam})
+			default:
+				filter.Actions = append(filter.Actions, FilterAction{Type: FilterTagSubject, Value: "[" + folder + "]"})
+			}
+		case "addflag", "setflag":
+			flags := []string{}
+			for _, arg := range action.args {
+				flags = append(flags, arg.strings...)
+			}
+			junk := false
+			for _, flag := range flags {
+				switch strings.ToLower(strings.TrimLeft(flag, "$\\")) {
+				case "junk", "spam":
+					junk = true
+				default:
+					c.fail(action.line, "flag %q is not supported", flag)
+				}
+			}
+			if junk {
+				filter.Actions = append(filter.Actions, FilterAction{Type: FilterMarkSpam})
+			}
+		case "if", "elsif", "else":
+			c.fail(action.line, "nested %s is not supported", action.name)
+		default:
+			c.fail(action.line, "command %q is not supported", action.name)
+		}
+	}
+
+	if len(filter.Actions) == 0 {
+		if filter.Stop {
+			c.fail(cmd.line, "stop without other actions is not supported")
+		}
+		return
+	}
+
+	c.rules = append(c.rules, SieveRule{Line: cmd.line, Filter: filter})
+}
+
+func (c *sieveCompiler) lastString(cmd sieveCommand) (string, bool) {
+	for i := len(cmd.args) - 1; i >= 0; i-- {
+		if len(cmd.args[i].strings) == 1 && !cmd.args[i].isList {
+			return cmd.args[i].strings[0], true
+		}
+	}
+
+	c.fail(cmd.line, "%s requires a string argument", cmd.name)
+	return "", false
+}
+
+// test compiles a test into a group of conditions. never is set for tests
+// that can't match (false), so their branch is skipped.
+func (c *sieveCompiler) test(t sieveTest) (group sieveGroup, never bool, ok bool) {
+	switch t.name {
+	case "true":
+		return sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}}, false, true
+	case "false":
+		return sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}}, true, true
+	case "not":
+		if len(t.tests) != 1 {
+			c.fail(t.line, "not requires a single test")
+			return sieveGroup{}, false, false
+		}
+		g, never, ok := c.test(t.tests[0])
+		if !ok {
+			return sieveGroup{}, false, false
+		}
+		if never {
+			return sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}}, false, true
+		}
+		return g.not(), false, true
+	case "allof", "anyof":
+		match := FilterMatchAll
+		if t.name == "anyof" {
+			match = FilterMatchAny
+		}
+		groups := []sieveGroup{}
+		for _, sub := range t.tests {
+			g, subNever, ok := c.test(sub)
+			if !ok {
+				return sieveGroup{}, false, false
+			}
+			if subNever {
+				if match == FilterMatchAll {
+					never = true
+				}
+				continue
+			}
+			groups = append(groups, g)
+		}
+		if len(groups) == 0 {
+			return sieveGroup{match: FilterMatchAll, conds: []FilterCondition{sieveTrue()}}, true, true
+		}
+		merged, ok := merge(match, groups)
+		if !ok {
+			c.fail(t.line, "nested allof/anyof combinations are not supported")
+			return sieveGroup{}, false, false
+		}
+		return merged, never, true
+	case "header", "address", "envelope":
+		return c.compare(t)
+	case "exists":
+		headers := c.stringArgs(t)
+		if len(headers) != 1 {
+			c.fail(t.line, "exists requires a header list")
+			return sieveGroup{}, false, false
+		}
+		g := sieveGroup{match: FilterMatchAll}
+		for _, h := range headers[0] {
+			g.conds = append(g.conds, FilterCondition{Field: FilterHeader, Header: h, Operator: FilterExists})
+		}
+		return g, false, true
+	case "size":
+		op := FilterOperator("")
+		value := ""
+		for _, arg := range t.args {
+			switch {
+			case arg.tag == "over":
+				op = FilterOver
+			case arg.tag == "under":
+				op = FilterUnder
+			case arg.number != "":
+				value = arg.number
+			}
+		}
+		if op == "" || value == "" {
+			c.fail(t.line, "size requires :over or :under and a number")
+			return sieveGroup{}, false, false
+		}
+		return sieveGroup{match: FilterMatchAll, conds: []FilterCondition{{Field: FilterSize, Operator: op, Value: value}}}, false, true
+	}
+
+	c.fail(t.line, "test %q is not supported", t.name)
+	return sieveGroup{}, false, false
+}
+
+// compare compiles header, address and envelope tests.
+func (c *sieveCompiler) compare(t sieveTest) (sieveGroup, bool, bool) {
+	op := FilterIs
+	part := FilterAddressPart("")
+	lists := [][]string{}
+	for i := 0; i < len(t.args); i++ {
+		arg := t.args[i]
+		switch arg.tag {
+		case "":
+			if arg.strings != nil {
+				lists = append(lists, arg.strings)
+			}
+		case "is":
+			op = FilterIs
+		case "contains":
+			op = FilterContains
+		case "matches":
+			op = FilterMatches
+		case "regex":
+			op = FilterRegex
+		case "all", "localpart", "domain":
+			if t.name == "header" {
+				c.fail(t.line, "address part :%s is not supported for header", arg.tag)
+				return sieveGroup{}, false, false
+			}
+			part = FilterAddressPart(arg.tag)
+		case "comparator":
+			if i+1 < len(t.args) && len(t.args[i+1].strings) == 1 {
+				comparator := strings.ToLower(t.args[i+1].strings[0])
+				if comparator != "i;ascii-casemap" {
+					c.fail(t.line, "comparator %q is not supported", comparator)
+					return sieveGroup{}, false, false
+				}
+				i++
+			}
+		default:
+			c.fail(t.line, "tag :%s is not supported", arg.tag)
+			return sieveGroup{}, false, false
+		}
+	}
+
+	if len(lists) != 2 {
+		c.fail(t.line, "%s requires a header list and a key list", t.name)
+		return sieveGroup{}, false, false
+	}
+
+	field := FilterHeader
+	switch t.name {
+	case "address":
+		field = FilterAddress
+	case "envelope":
+		field = FilterEnvelope
+	}
+
+	g := sieveGroup{match: FilterMatchAny}
+	for _, h := range lists[0] {
+		if field == FilterEnvelope {
+			h = strings.ToLower(h)
+			if h != "from" && h != "to" {
+				c.fail(t.line, "envelope part %q is not supported", h)
+				return sieveGroup{}, false, false
+			}
+		}
+
+		cond := FilterCondition{Field: field, Header: h, Part: part, Operator: op}
+		if len(lists[1]) == 1 {
+			cond.Value = lists[1][0]
+		} else {
+			cond.Values = lists[1]
+		}
+		g.conds = append(g.conds, cond)
+	}
+
+	return g, false, true
+}
+
+func (c *sieveCompiler) stringArgs(t sieveTest) [][]string {
+	lists := [][]string{}
+	for _, arg := range t.args {
+		if arg.tag != "" {
+			c.fail(t.line, "tag :%s is not supported", arg.tag)
+			continue
+		}
+		if arg.strings != nil {
+			lists = append(lists, arg.strings)
+		}
+	}
+	return lists
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"net/mail"
+	"testing"
+)
+
+func TestParseSieve(t *testing.T) {
+	script := `require ["fileinto", "imap4flags"];
+# Mailing lists
+if header :is "List-Id" "<weekly.example.com>" {
+    fileinto "News";
+    stop;
+}
+elsif address :domain :is "from" ["spam.com", "junk.com"] {
+    fileinto "Junk";
+}
+else {
+    keep;
+}
+if allof (size :over 5M, not exists "X-Trusted") {
+    discard;
+}
+if envelope :is "from" "boss@example.com" {
+    redirect "me@example.net";
+    addflag "\\Flagged";
+}
+if body :contains "invoice" {
+    discard;
+}
+vacation "I'm away";
+`
+
+	rules, errs := ParseSieve(script)
+
+	if len(rules) != 4 {
+		t.Fatalf("expected 4 rules, got %d: %+v", len(rules), rules)
+	}
+
+	wantErrLines := []int{18, 20, 23}
+	if len(errs) != len(wantErrLines) {
+		t.Fatalf("expected %d errors, got %+v", len(wantErrLines), errs)
+	}
+	for i, line := range wantErrLines {
+		if errs[i].Line != line {
+			t.Errorf("error %d line = %d, want %d (%s)", i, errs[i].Line, line, errs[i].Message)
+		}
+	}
+
+	for _, r := range rules {
+		if err := r.Filter.Validate(); err != nil {
+			t.Errorf("rule at line %d is invalid: %+v", r.Line, r.Filter)
+		}
+	}
+
+	news := Msg{Subject: "Hi", Headers: mail.Header{"List-Id": {"<weekly.example.com>"}, "From": {"news@spam.com"}}, Size: 100}
+	spam := Msg{Subject: "Hi", Headers: mail.Header{"From": {"Bob <bob@junk.com>"}}, Size: 100}
+	big := Msg{Subject: "Hi", Headers: mail.Header{"From": {"a@example.org"}}, Size: 6 * 1024 * 1024}
+	trusted := Msg{Subject: "Hi", Headers: mail.Header{"From": {"a@example.org"}, "X-Trusted": {"yes"}}, Size: 6 * 1024 * 1024}
+	boss := Msg{Subject: "Hi", Headers: mail.Header{}, Envelope: Envelope{From: "boss@example.com"}}
+
+	filters := []Filter{}
+	for _, r := range rules {
+		filters = append(filters, r.Filter)
+	}
+
+	tests := []struct {
+		name string
+		msg  Msg
+		want FilterResult
+	}{
+		{"if branch", news, FilterResult{SubjectTag: "[News]"}},
+		{"elsif branch", spam, FilterResult{Spam: true}},
+		{"allof with not", big, FilterResult{Drop: true}},
+		{"not exists fails", trusted, FilterResult{}},
+		{"envelope redirect", boss, FilterResult{ForwardTo: "me@example.net"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EvalFilters(filters, "", tt.msg)
+			if got.Drop != tt.want.Drop || got.ForwardTo != tt.want.ForwardTo || got.SubjectTag != tt.want.SubjectTag || got.Spam != tt.want.Spam {
+				t.Errorf("EvalFilters() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSieveSyntaxError(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		line   int
+	}{
+		{"unterminated string", "if header :is \"Subject\" \"x {\n discard;\n}\n", 1},
+		{"missing semicolon", "if true {\n discard\n}", 3},
+		{"missing brace", "if true {\n discard;\n", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, errs := ParseSieve(tt.script)
+			if len(rules) != 0 || len(errs) != 1 {
+				t.Fatalf("expected a single error, got %+v %+v", rules, errs)
+			}
+			if errs[0].Line != tt.line {
+				t.Errorf("error line = %d, want %d (%s)", errs[0].Line, tt.line, errs[0].Message)
+			}
+		})
+	}
+}
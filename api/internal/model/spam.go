@@ -0,0 +1,94 @@
+package model
+
+import (
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type SpamPolicy string
+
+const (
+	SpamInherit SpamPolicy = "" // alias only, use the account setting
+	SpamForward SpamPolicy = "forward"
+	SpamTag     SpamPolicy = "tag"
+	SpamDrop    SpamPolicy = "drop"
+)
+
+const SpamSubjectTag = "[SPAM]"
+
+var spamdResultRE = regexp.MustCompile(`(?i)^\s*\w+\s*:\s*(true|false)\s*\[\s*(-?[\d.]+)\s*/\s*(-?[\d.]+)\s*\]`)
+
+// SpamScore is the rspamd verdict parsed from the message headers.
+type SpamScore struct {
+	Found     bool
+	Spam      bool
+	Score     float64
+	Threshold float64
+}
+
+// ParseSpamScore reads the rspamd headers added by the mailserver:
+// X-Spam (Yes/No) and X-Spamd-Result (default: True [15.20 / 15.00]; ...).
+// The message is spam when rspamd flagged it, or when the score reaches the
+// threshold. X-Rspamd-Score is not added by the mailserver, so a copy set by
+// the sender is ignored. rspamd appends its headers, so when the sender set
+// its own copy above, the last instance is read.
+func ParseSpamScore(h mail.Header) SpamScore {
+	score := SpamScore{}
+
+	if v := lastHeader(h, "X-Spamd-Result"); v != "" {
+		if m := spamdResultRE.FindStringSubmatch(v); m != nil {
+			score.Found = true
+			score.Spam = strings.EqualFold(m[1], "true")
+			score.Score, _ = strconv.ParseFloat(m[2], 64)
+			score.Threshold, _ = strconv.ParseFloat(m[3], 64)
+		}
+	}
+
+	if v := lastHeader(h, "X-Spam"); v != "" {
+		score.Found = true
+		if strings.EqualFold(v, "yes") || strings.EqualFold(v, "true") {
+			score.Spam = true
+		}
+	}
+
+	if score.Threshold > 0 && score.Score >= score.Threshold {
+		score.Spam = true
+	}
+
+	return score
+}
+
+// lastHeader returns the trimmed value of the last instance of a header.
+func lastHeader(h mail.Header, key string) string {
+	values := h[textproto.CanonicalMIMEHeaderKey(key)]
+	if len(values) == 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(values[len(values)-1])
+}
+
+// GetSpamPolicy returns the alias policy, falling back to the account setting.
+func GetSpamPolicy(alias Alias, settings Settings) SpamPolicy {
+	if alias.SpamPolicy != SpamInherit {
+		return alias.SpamPolicy
+	}
+
+	if settings.SpamPolicy != SpamInherit {
+		return settings.SpamPolicy
+	}
+
+	return SpamForward
+}
+
+// TagSpamSubject prefixes the subject with the spam tag once.
+func TagSpamSubject(subject string) string {
+	if strings.HasPrefix(subject, SpamSubjectTag) {
+		return subject
+	}
+
+	return SpamSubjectTag + " " + subject
+}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"net/mail"
+	"testing"
+)
+
+func TestParseSpamScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		header mail.Header
+		want   SpamScore
+	}{
+		{
+			name:   "no headers",
+			header: mail.Header{},
+			want:   SpamScore{},
+		},
+		{
+			name:   "spamd result spam",
+			header: mail.Header{"X-Spamd-Result": {"default: True [16.40 / 15.00];\r\n\tBAYES_SPAM(5.10)[99.99%]"}},
+			want:   SpamScore{Found: true, Spam: true, Score: 16.4, Threshold: 15},
+		},
+		{
+			name:   "spamd result ham",
+			header: mail.Header{"X-Spamd-Result": {"default: False [-0.10 / 15.00]; ARC_NA(0.00)[]"}},
+			want:   SpamScore{Found: true, Spam: false, Score: -0.1, Threshold: 15},
+		},
+		{
+			name:   "x-spam yes",
+			header: mail.Header{"X-Spam": {"Yes"}},
+			want:   SpamScore{Found: true, Spam: true},
+		},
+		{
+			name:   "score over threshold",
+			header: mail.Header{"X-Spamd-Result": {"default: False [7.50 / 6.00]"}},
+			want:   SpamScore{Found: true, Spam: true, Score: 7.5, Threshold: 6},
+		},
+		{
+			name:   "sender rspamd score ignored",
+			header: mail.Header{"X-Spamd-Result": {"default: False [3.00 / 6.00]"}, "X-Rspamd-Score": {"20"}},
+			want:   SpamScore{Found: true, Score: 3, Threshold: 6},
+		},
+		{
+			name: "forged spamd result ignored",
+			header: mail.Header{
+				"X-Spamd-Result": {"default: False [-5.00 / 15.00]", "default: True [18.00 / 15.00]"},
+				"X-Spam":         {"No", "Yes"},
+			},
+			want: SpamScore{Found: true, Spam: true, Score: 18, Threshold: 15},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSpamScore(tt.header); got != tt.want {
+				t.Errorf("ParseSpamScore() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetSpamPolicy(t *testing.T) {
+	tests := []struct {
+		alias    SpamPolicy
+		settings SpamPolicy
+		want     SpamPolicy
+	}{
+		{SpamInherit, SpamInherit, SpamForward},
+		{SpamInherit, SpamDrop, SpamDrop},
+		{SpamTag, SpamDrop, SpamTag},
+		{SpamForward, SpamTag, SpamForward},
+	}
+
+	for _, tt := range tests {
+		got := GetSpamPolicy(Alias{SpamPolicy: tt.alias}, Settings{SpamPolicy: tt.settings})
+		if got != tt.want {
+			t.Errorf("GetSpamPolicy(%q, %q) = %q, want %q", tt.alias, tt.settings, got, tt.want)
+		}
+	}
+}
+
+func TestTagSpamSubject(t *testing.T) {
+	if got := TagSpamSubject("Hello"); got != "[SPAM] Hello" {
+		t.Errorf("TagSpamSubject() = %q", got)
+	}
+	if got := TagSpamSubject("[SPAM] Hello"); got != "[SPAM] Hello" {
+		t.Errorf("TagSpamSubject() tagged twice = %q", got)
+	}
+}
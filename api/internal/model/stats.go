@@ -0,0 +1,117 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidStatsRange = errors.New("invalid stats range")
+)
+
+type StatsBucket string
+
+const (
+	BucketHour  StatsBucket = "hour"
+	BucketDay   StatsBucket = "day"
+	BucketWeek  StatsBucket = "week"
+	BucketMonth StatsBucket = "month"
+)
+
+// MaxStatsPoints limits the number of buckets of a series.
+const MaxStatsPoints = 1000
+
+type StatsPoint struct {
+	Time     time.Time `json:"time"`
+	Forwards int       `json:"forwards"`
+	Blocks   int       `json:"blocks"`
+	Replies  int       `json:"replies"`
+	Sends    int       `json:"sends"`
+	Bounces  int       `json:"bounces"`
+	Spam     int       `json:"spam"`
+}
+
+type StatsSeries struct {
+	From   time.Time    `json:"from"`
+	To     time.Time    `json:"to"`
+	Bucket StatsBucket  `json:"bucket"`
+	Points []StatsPoint `json:"points"`
+}
+
+// Truncate returns the start of the bucket containing t, weeks start on Monday.
+func (b StatsBucket) Truncate(t time.Time) time.Time {
+	t = t.UTC()
+	switch b {
+	case BucketHour:
+		return t.Truncate(time.Hour)
+	case BucketWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case BucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Next returns the start of the bucket following t.
+func (b StatsBucket) Next(t time.Time) time.Time {
+	switch b {
+	case BucketHour:
+		return t.Add(time.Hour)
+	case BucketWeek:
+		return t.AddDate(0, 0, 7)
+	case BucketMonth:
+		return t.AddDate(0, 1, 0)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+func (b StatsBucket) Valid() bool {
+	return b == BucketHour || b == BucketDay || b == BucketWeek || b == BucketMonth
+}
+
+// NewStatsSeries validates the range and returns an empty series with one
+// point per bucket in [from, to).
+func NewStatsSeries(from time.Time, to time.Time, bucket StatsBucket) (StatsSeries, error) {
+	if !bucket.Valid() || !from.Before(to) {
+		return StatsSeries{}, ErrInvalidStatsRange
+	}
+
+	series := StatsSeries{From: from.UTC(), To: to.UTC(), Bucket: bucket, Points: []StatsPoint{}}
+	for t := bucket.Truncate(from); t.Before(to); t = bucket.Next(t) {
+		if len(series.Points) == MaxStatsPoints {
+			return StatsSeries{}, ErrInvalidStatsRange
+		}
+		series.Points = append(series.Points, StatsPoint{Time: t})
+	}
+
+	return series, nil
+}
+
+// Add counts messages of the given type at time t.
+func (s *StatsSeries) Add(t time.Time, msgType MessageType, count int) {
+	start := s.Bucket.Truncate(t)
+	for i := range s.Points {
+		if !s.Points[i].Time.Equal(start) {
+			continue
+		}
+
+		switch msgType {
+		case Forward:
+			s.Points[i].Forwards += count
+		case Block:
+			s.Points[i].Blocks += count
+		case Reply:
+			s.Points[i].Replies += count
+		case Send:
+			s.Points[i].Sends += count
+		case FailBounce:
+			s.Points[i].Bounces += count
+		case Spam:
+			s.Points[i].Spam += count
+		}
+		return
+	}
+}
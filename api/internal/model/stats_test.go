@@ -0,0 +1,61 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatsBucketTruncate(t *testing.T) {
+	ts := time.Date(2026, 10, 15, 13, 45, 10, 0, time.UTC) // Thursday
+
+	tests := []struct {
+		bucket StatsBucket
+		want   time.Time
+	}{
+		{BucketHour, time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC)},
+		{BucketDay, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{BucketWeek, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)},
+		{BucketMonth, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.bucket.Truncate(ts); !got.Equal(tt.want) {
+			t.Errorf("%s Truncate() = %v, want %v", tt.bucket, got, tt.want)
+		}
+	}
+}
+
+func TestNewStatsSeries(t *testing.T) {
+	from := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 4, 0, 0, 0, 0, time.UTC)
+
+	series, err := NewStatsSeries(from, to, BucketDay)
+	if err != nil {
+		t.Fatalf("NewStatsSeries() error = %v", err)
+	}
+	if len(series.Points) != 3 {
+		t.Fatalf("expected 3 points, got %d", len(series.Points))
+	}
+
+	series.Add(time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC), Forward, 4)
+	series.Add(time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC), Spam, 2)
+	series.Add(time.Date(2026, 10, 3, 9, 0, 0, 0, time.UTC), FailBounce, 1)
+	series.Add(time.Date(2026, 11, 3, 9, 0, 0, 0, time.UTC), Forward, 1) // out of range
+
+	if p := series.Points[1]; p.Forwards != 4 || p.Spam != 2 {
+		t.Errorf("unexpected point %+v", p)
+	}
+	if p := series.Points[2]; p.Bounces != 1 || p.Forwards != 0 {
+		t.Errorf("unexpected point %+v", p)
+	}
+
+	if _, err := NewStatsSeries(to, from, BucketDay); err == nil {
+		t.Error("expected error for reversed range")
+	}
+	if _, err := NewStatsSeries(from, to, "year"); err == nil {
+		t.Error("expected error for invalid bucket")
+	}
+	if _, err := NewStatsSeries(from, from.AddDate(1, 0, 0), BucketHour); err == nil {
+		t.Error("expected error for too many points")
+	}
+}
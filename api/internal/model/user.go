@@ -31,6 +31,7 @@ type UserStats struct {
 	Blocks   int   `json:"blocks"`
 	Replies  int   `json:"replies"`
 	Sends    int   `json:"sends"`
+	Spam     int   `json:"spam"`
 	Aliases  int64 `json:"aliases"`
 	Messages []any `json:"messages" gorm:"type:text"`
 }
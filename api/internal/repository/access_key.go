@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"ivpn.net/email/api/internal/model"
 )
@@ -31,10 +32,31 @@ func (d *Database) PostAccessKey(ctx context.Context, accessKey model.AccessKey)
 	return accessKey, err
 }
 
+func (d *Database) GetAccessKeyUsage(ctx context.Context, accessKeyIDs []string, since time.Time) ([]model.AccessKeyUsage, error) {
+	var usage []model.AccessKeyUsage
+	err := d.Client.Where("access_key_id IN ? AND date >= ?", accessKeyIDs, since).Order("date asc").Find(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
 func (d *Database) DeleteAccessKey(ctx context.Context, accessKeyID string, userId string) error {
-	return d.Client.Where("id = ? AND user_id = ?", accessKeyID, userId).Delete(&model.AccessKey{}).Error
+	q := d.Client.Where("id = ? AND user_id = ?", accessKeyID, userId).Delete(&model.AccessKey{})
+	if q.Error != nil || q.RowsAffected == 0 {
+		return q.Error
+	}
+
+	return d.Client.Where("access_key_id = ?", accessKeyID).Delete(&model.AccessKeyUsage{}).Error
 }
 
 func (d *Database) DeleteAccessKeysByUserID(ctx context.Context, userId string) error {
+	keys := d.Client.Model(&model.AccessKey{}).Select("id").Where("user_id = ?", userId)
+	err := d.Client.Where("access_key_id IN (?)", keys).Delete(&model.AccessKeyUsage{}).Error
+	if err != nil {
+		return err
+	}
+
 	return d.Client.Where("user_id = ?", userId).Delete(&model.AccessKey{}).Error
 }
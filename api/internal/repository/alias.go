@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"strconv"
+	"time"
 
+	"gorm.io/gorm"
 	"ivpn.net/email/api/internal/model"
 )
 
@@ -20,8 +22,10 @@ func (d *Database) GetAlias(ctx context.Context, ID string, userID string) (mode
 		Select("SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as forwards, "+
 			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as blocks, "+
 			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as replies, "+
-			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as sends",
-			model.Forward, model.Block, model.Reply, model.Send).
+			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as sends, "+
+			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as duplicates, "+
+			"SUM(CASE WHEN type = ? THEN 1 ELSE 0 END) as spam",
+			model.Forward, model.Block, model.Reply, model.Send, model.Duplicate, model.Spam).
 		Where("alias_id = ?", ID).
 		Scan(&aliasStats).Error
 	if err != nil {
@@ -59,11 +63,16 @@ func (d *Database) GetAliases(ctx context.Context, userID string, limit int, off
 
 	aliases := []model.Alias{}
 	query := `
-		SELECT a.*,
+		SELECT a.id, a.created_at, a.updated_at, a.deleted_at, a.name, a.user_id, a.enabled,
+			a.description, a.recipients, a.from_name, a.catch_all, a.spam_policy,
+			a.paused_at, a.throttled_until, a.expires_at, a.max_messages, a.received,
+			a.schedule,
 			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS forwards,
 			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS blocks,
 			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS replies,
-			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS sends
+			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS sends,
+			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS duplicates,
+			COALESCE(SUM(CASE WHEN m.type = ? THEN 1 ELSE 0 END), 0) AS spam
 		FROM aliases a
 		LEFT JOIN messages m
 		ON a.id = m.alias_id
@@ -79,25 +88,22 @@ func (d *Database) GetAliases(ctx context.Context, userID string, limit int, off
 		query += "\nOFFSET " + strconv.Itoa(offset)
 	}
 
-	rows, err := d.Client.Raw(query, model.Forward, model.Block, model.Reply, model.Send, userID).Rows()
+	rows, err := d.Client.Raw(query, model.Forward, model.Block, model.Reply, model.Send, model.Duplicate, model.Spam, userID).Rows()
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var alias model.Alias
-		var forwards, blocks, replies, sends int
-		if err := rows.Scan(&alias.ID, &alias.CreatedAt, &alias.UpdatedAt, &alias.DeletedAt, &alias.Name, &alias.UserID, &alias.Enabled, &alias.Description, &alias.Recipients, &alias.FromName, &alias.CatchAll, &forwards, &blocks, &replies, &sends); err != nil {
-			return nil, err
+		var row struct {
+			model.Alias
+			model.AliasStats
 		}
-		alias.Stats = model.AliasStats{
-			Forwards: forwards,
-			Blocks:   blocks,
-			Replies:  replies,
-			Sends:    sends,
+		if err := d.Client.ScanRows(rows, &row); err != nil {
+			return nil, err
 		}
-		aliases = append(aliases, alias)
+		row.Alias.Stats = row.AliasStats
+		aliases = append(aliases, row.Alias)
 	}
 
 	return aliases, nil
@@ -158,14 +164,36 @@ func (d *Database) PostAlias(ctx context.Context, alias model.Alias) (model.Alia
 }
 
 func (d *Database) UpdateAlias(ctx context.Context, alias model.Alias) error {
-	return d.Client.Model(&alias).Where("user_id = ?", alias.UserID).Updates(map[string]any{
-		"description": alias.Description,
-		"enabled":     alias.Enabled,
-		"recipients":  alias.Recipients,
-		"from_name":   alias.FromName,
+	columns := []any{"enabled", "recipients", "from_name", "spam_policy", "expires_at", "max_messages", "schedule"}
+
+	// Enabling a paused alias again lifts the pause
+	if alias.Enabled {
+		alias.PausedAt = nil
+		columns = append(columns, "paused_at")
+	}
+
+	return d.Client.Model(&alias).Where("user_id = ?", alias.UserID).Select("description", columns...).Updates(&alias).Error
+}
+
+func (d *Database) PauseAlias(ctx context.Context, ID string, pausedAt time.Time) error {
+	return d.Client.Model(&model.Alias{}).Where("id = ?", ID).Updates(map[string]any{
+		"enabled":   false,
+		"paused_at": pausedAt,
 	}).Error
 }
 
+func (d *Database) DisableAlias(ctx context.Context, ID string) error {
+	return d.Client.Model(&model.Alias{}).Where("id = ?", ID).Update("enabled", false).Error
+}
+
+func (d *Database) IncrementAliasReceived(ctx context.Context, ID string) error {
+	return d.Client.Model(&model.Alias{}).Where("id = ?", ID).UpdateColumn("received", gorm.Expr("received + 1")).Error
+}
+
+func (d *Database) ThrottleAlias(ctx context.Context, ID string, until time.Time) error {
+	return d.Client.Model(&model.Alias{}).Where("id = ?", ID).Update("throttled_until", until).Error
+}
+
 func (d *Database) DeleteAlias(ctx context.Context, ID string, userID string) error {
 	return d.Client.Where("id = ? AND user_id = ?", ID, userID).Delete(&model.Alias{}).Error
 }
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+func (d *Database) GetAuditEvents(ctx context.Context, userID string, limit int, offset int) ([]model.AuditEvent, error) {
+	var events []model.AuditEvent
+	err := d.Client.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Offset(offset).Find(&events).Error
+	return events, err
+}
+
+func (d *Database) GetAuditEventCount(ctx context.Context, userID string) (int, error) {
+	var count int64
+	err := d.Client.Model(&model.AuditEvent{}).Where("user_id = ?", userID).Count(&count).Error
+	return int(count), err
+}
+
+func (d *Database) PostAuditEvent(ctx context.Context, event model.AuditEvent) error {
+	return d.Client.Create(&event).Error
+}
+
+func (d *Database) DeleteAuditEventsByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.AuditEvent{}).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"ivpn.net/email/api/internal/model"
+)
+
+func (d *Database) GetContacts(ctx context.Context, aliasID string, userID string) ([]model.Contact, error) {
+	var contacts []model.Contact
+	err := d.Client.Where("alias_id = ? AND user_id = ?", aliasID, userID).Order("last_seen_at desc, created_at desc").Find(&contacts).Error
+	return contacts, err
+}
+
+func (d *Database) GetContact(ctx context.Context, aliasName string, address string) (model.Contact, error) {
+	var contact model.Contact
+	err := d.Client.Where("alias_name = ? AND address = ?", aliasName, address).First(&contact).Error
+	return contact, err
+}
+
+func (d *Database) GetContactByID(ctx context.Context, contactID string, userID string) (model.Contact, error) {
+	var contact model.Contact
+	err := d.Client.Where("id = ? AND user_id = ?", contactID, userID).First(&contact).Error
+	return contact, err
+}
+
+func (d *Database) GetContactByToken(ctx context.Context, token string) (model.Contact, error) {
+	var contact model.Contact
+	err := d.Client.Where("token = ?", token).First(&contact).Error
+	return contact, err
+}
+
+// PostContact creates a contact, unless the alias already has one for the
+// address. It reports whether the contact was created.
+func (d *Database) PostContact(ctx context.Context, contact model.Contact) (bool, error) {
+	res := d.Client.Clauses(clause.OnConflict{DoNothing: true}).Create(&contact)
+	return res.RowsAffected > 0, res.Error
+}
+
+// SeeContact records a message from the contact, creating it when the alias
+// has none for the address yet.
+func (d *Database) SeeContact(ctx context.Context, contact model.Contact, seenAt time.Time) error {
+	contact.FirstSeenAt = &seenAt
+	contact.LastSeenAt = &seenAt
+	contact.Messages = 1
+
+	return d.Client.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "alias_name"}, {Name: "address"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"first_seen_at": gorm.Expr("COALESCE(first_seen_at, ?)", seenAt),
+			"last_seen_at":  seenAt,
+			"messages":      gorm.Expr("messages + 1"),
+		}),
+	}).Create(&contact).Error
+}
+
+func (d *Database) UpdateContactBlocked(ctx context.Context, contactID string, userID string, blocked bool) error {
+	return d.Client.Model(&model.Contact{}).Where("id = ? AND user_id = ?", contactID, userID).Update("blocked", blocked).Error
+}
+
+func (d *Database) DeleteContactsByAliasID(ctx context.Context, aliasID string, userID string) error {
+	return d.Client.Where("alias_id = ? AND user_id = ?", aliasID, userID).Delete(&model.Contact{}).Error
+}
+
+func (d *Database) DeleteContactsByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.Contact{}).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"encoding/json"
 	"log"
 	"time"
 
@@ -108,12 +109,36 @@ func migrate(db *gorm.DB) error {
 		&model.Credential{},
 		&model.Log{},
 		&model.AccessKey{},
+		&model.AccessKeyUsage{},
+		&model.AuditEvent{},
 		&model.Domain{},
+		&model.Delivery{},
+		&model.Rule{},
+		&model.Filter{},
+		&model.DomainKey{},
+		&model.Contact{},
+		&model.Quarantine{},
 	)
 	if err != nil {
 		return err
 	}
 
+	// Access keys created before usage tracking count as unused from now on
+	err = db.Model(&model.AccessKey{}).Where("tracked_since IS NULL").Update("tracked_since", time.Now()).Error
+	if err != nil {
+		return err
+	}
+
+	// Access keys created before scopes existed keep the alias scopes they had
+	scopes, err := json.Marshal(model.AliasScopes)
+	if err != nil {
+		return err
+	}
+	err = db.Model(&model.AccessKey{}).Where("scopes IS NULL OR scopes IN ?", []string{"", "null", "[]"}).Update("scopes", string(scopes)).Error
+	if err != nil {
+		return err
+	}
+
 	log.Println("DB migration OK")
 
 	return nil
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+	"ivpn.net/email/api/internal/model"
+)
+
+// PostDelivery inserts the delivery unless one with the same idempotency key
+// already exists. It reports whether a new delivery was queued.
+func (d *Database) PostDelivery(ctx context.Context, delivery model.Delivery) (bool, error) {
+	res := d.Client.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+	return res.RowsAffected > 0, res.Error
+}
+
+// ClaimDeliveries leases up to limit due deliveries to the caller. A lease
+// that is not released before it expires (e.g. worker crash) is picked up again.
+func (d *Database) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.Delivery, error) {
+	var deliveries []model.Delivery
+	token := uuid.New().String()
+	now := time.Now()
+
+	err := d.Client.Model(&model.Delivery{}).
+		Where("status = ? AND next_attempt_at <= ? AND (locked_until IS NULL OR locked_until < ?)", model.DeliveryPending, now, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Updates(map[string]any{
+			"lock_token":   token,
+			"locked_until": now.Add(lease),
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.Client.Where("lock_token = ? AND status = ?", token, model.DeliveryPending).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (d *Database) UpdateDelivery(ctx context.Context, delivery model.Delivery) error {
+	return d.Client.Model(&model.Delivery{}).Where("id = ?", delivery.ID).Updates(map[string]any{
+		"status":          delivery.Status,
+		"attempts":        delivery.Attempts,
+		"next_attempt_at": delivery.NextAttemptAt,
+		"locked_until":    delivery.LockedUntil,
+		"lock_token":      delivery.LockToken,
+		"last_error":      delivery.LastError,
+		"data":            delivery.Data,
+	}).Error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+func (d *Database) GetDomainKeys(ctx context.Context, domainID string, userID string) ([]model.DomainKey, error) {
+	var keys []model.DomainKey
+	err := d.Client.Where("domain_id = ? AND user_id = ?", domainID, userID).Order("created_at desc").Find(&keys).Error
+	return keys, err
+}
+
+func (d *Database) GetDomainKeysByName(ctx context.Context, domainName string) ([]model.DomainKey, error) {
+	var keys []model.DomainKey
+	err := d.Client.Joins("JOIN domains ON domains.id = domain_keys.domain_id").
+		Where("domains.name = ?", domainName).
+		Order("domain_keys.created_at desc").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (d *Database) PostDomainKey(ctx context.Context, key model.DomainKey) (model.DomainKey, error) {
+	err := d.Client.Create(&key).Error
+	return key, err
+}
+
+func (d *Database) UpdateDomainKeyStatus(ctx context.Context, keyID string, status model.DomainKeyStatus) error {
+	return d.Client.Model(&model.DomainKey{}).Where("id = ?", keyID).Update("status", status).Error
+}
+
+func (d *Database) DeleteDomainKey(ctx context.Context, keyID string) error {
+	return d.Client.Where("id = ?", keyID).Delete(&model.DomainKey{}).Error
+}
+
+func (d *Database) DeleteDomainKeysByDomainID(ctx context.Context, domainID string, userID string) error {
+	return d.Client.Where("domain_id = ? AND user_id = ?", domainID, userID).Delete(&model.DomainKey{}).Error
+}
+
+func (d *Database) DeleteDomainKeysByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.DomainKey{}).Error
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+func (d *Database) GetFilters(ctx context.Context, userID string) ([]model.Filter, error) {
+	var filters []model.Filter
+	err := d.Client.Where("user_id = ?", userID).Order("position asc, created_at asc").Find(&filters).Error
+	return filters, err
+}
+
+func (d *Database) GetFilter(ctx context.Context, filterID string, userID string) (model.Filter, error) {
+	var filter model.Filter
+	err := d.Client.Where("id = ? AND user_id = ?", filterID, userID).First(&filter).Error
+	return filter, err
+}
+
+func (d *Database) GetAliasFilters(ctx context.Context, userID string, aliasID string) ([]model.Filter, error) {
+	var filters []model.Filter
+	err := d.Client.Where("user_id = ? AND (alias_id = '' OR alias_id = ?)", userID, aliasID).Order("position asc, created_at asc").Find(&filters).Error
+	return filters, err
+}
+
+func (d *Database) PostFilter(ctx context.Context, filter model.Filter) (model.Filter, error) {
+	err := d.Client.Create(&filter).Error
+	return filter, err
+}
+
+func (d *Database) UpdateFilter(ctx context.Context, filter model.Filter) error {
+	return d.Client.Model(&filter).Where("user_id = ?", filter.UserID).Select(
+		"alias_id", "name", "match", "conditions", "actions", "stop", "enabled", "position",
+	).Updates(&filter).Error
+}
+
+func (d *Database) DeleteFilter(ctx context.Context, filterID string, userID string) error {
+	return d.Client.Where("id = ? AND user_id = ?", filterID, userID).Delete(&model.Filter{}).Error
+}
+
+func (d *Database) DeleteFiltersByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.Filter{}).Error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"ivpn.net/email/api/internal/model"
 )
@@ -30,8 +31,29 @@ func (d *Database) DeleteMessage(ctx context.Context, messageID uint, userID str
 	return d.Client.Where("id = ? AND user_id = ?", messageID, userID).Delete(&model.Message{}).Error
 }
 
+// SendReplyDailyCount counts the replies and sends of the last day, copies
+// still queued for delivery included.
 func (d *Database) SendReplyDailyCount(ctx context.Context, userID string) (int, error) {
 	var count int64
 	err := d.Client.Model(&model.Message{}).Where("user_id = ? AND type IN (?, ?) AND created_at > NOW() - INTERVAL 1 DAY", userID, model.Reply, model.Send).Count(&count).Error
-	return int(count), err
+	if err != nil {
+		return 0, err
+	}
+
+	var pending int64
+	err = d.Client.Model(&model.Delivery{}).Where("user_id = ? AND type IN (?, ?) AND status = ?", userID, model.Reply, model.Send, model.DeliveryPending).Count(&pending).Error
+	return int(count + pending), err
+}
+
+func (d *Database) GetMessageCounts(ctx context.Context, userID string, aliasID string, from time.Time, to time.Time) ([]model.MessageCount, error) {
+	var counts []model.MessageCount
+	query := d.Client.Model(&model.Message{}).
+		Select("MIN(created_at) AS time, type, COUNT(*) AS count").
+		Where("user_id = ? AND created_at >= ? AND created_at < ?", userID, from, to)
+	if aliasID != "" {
+		query = query.Where("alias_id = ?", aliasID)
+	}
+
+	err := query.Group("DATE_FORMAT(created_at, '%Y-%m-%d %H'), type").Scan(&counts).Error
+	return counts, err
 }
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+const (
+	quarantineBaseDir = "/var/log/quarantine"
+)
+
+func (d *Database) GetQuarantine(ctx context.Context, userID string) ([]model.Quarantine, error) {
+	var messages []model.Quarantine
+	err := d.Client.Where("user_id = ?", userID).Order("created_at desc").Find(&messages).Error
+	return messages, err
+}
+
+func (d *Database) GetQuarantineMessage(ctx context.Context, ID string, userID string) (model.Quarantine, error) {
+	var message model.Quarantine
+	err := d.Client.Where("id = ? AND user_id = ?", ID, userID).First(&message).Error
+	return message, err
+}
+
+func (d *Database) GetQuarantineUsage(ctx context.Context, userID string) (model.QuarantineUsage, error) {
+	var usage model.QuarantineUsage
+	err := d.Client.Model(&model.Quarantine{}).
+		Select("COUNT(*) as count, COALESCE(SUM(size), 0) as size").
+		Where("user_id = ?", userID).
+		Scan(&usage).Error
+	return usage, err
+}
+
+func (d *Database) PostQuarantine(ctx context.Context, message model.Quarantine) (model.Quarantine, error) {
+	err := d.Client.Create(&message).Error
+	return message, err
+}
+
+func (d *Database) DeleteQuarantine(ctx context.Context, ID string, userID string) error {
+	return d.Client.Where("id = ? AND user_id = ?", ID, userID).Delete(&model.Quarantine{}).Error
+}
+
+func (d *Database) DeleteQuarantineByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.Quarantine{}).Error
+}
+
+func (d *Database) SaveQuarantineFile(ctx context.Context, filename string, data []byte) error {
+	filePath := quarantineBaseDir + "/" + filename + ".eml"
+
+	// Ensure the directory exists
+	dir := filepath.Dir(filePath)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		log.Println("error creating quarantine directory:", err)
+		return err
+	}
+
+	// Write the file
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		log.Println("error writing quarantine file:", err)
+		return err
+	}
+
+	return nil
+}
+
+func (d *Database) GetQuarantineFile(ctx context.Context, filename string) ([]byte, error) {
+	fullPath, err := quarantinePath(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(fullPath)
+}
+
+func (d *Database) DeleteQuarantineFile(ctx context.Context, filename string) error {
+	fullPath, err := quarantinePath(filename)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func quarantinePath(filename string) (string, error) {
+	fullPath := filepath.Join(quarantineBaseDir, filepath.Clean(filename+".eml"))
+
+	// Ensure fullPath is still within baseDir
+	if filepath.Dir(fullPath) != quarantineBaseDir {
+		return "", errors.New("invalid file path: " + filename)
+	}
+
+	return fullPath, nil
+}
@@ -101,19 +101,23 @@ func (r *Redis) Get(ctx context.Context, key string) (string, error) {
 	return r.Client.Get(ctx, key).Result()
 }
 
+func (r *Redis) SetNX(ctx context.Context, key string, value any, expiration time.Duration) (bool, error) {
+	return r.Client.SetNX(ctx, key, value, expiration).Result()
+}
+
 func (r *Redis) Del(ctx context.Context, key string) error {
 	return r.Client.Del(ctx, key).Err()
 }
 
-func (c *Redis) Incr(ctx context.Context, key string, expiration time.Duration) error {
-	err := c.Client.Incr(ctx, key).Err()
+func (c *Redis) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	value, err := c.Client.Incr(ctx, key).Result()
 	if err != nil {
-		return err
+		return 0, err
 	}
 
 	if expiration > 0 {
-		return c.Client.Expire(ctx, key, expiration).Err()
+		return value, c.Client.Expire(ctx, key, expiration).Err()
 	}
 
-	return nil
+	return value, nil
 }
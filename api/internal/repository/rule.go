@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+func (d *Database) GetRules(ctx context.Context, userID string) ([]model.Rule, error) {
+	var rules []model.Rule
+	err := d.Client.Where("user_id = ?", userID).Order("created_at desc").Find(&rules).Error
+	return rules, err
+}
+
+func (d *Database) GetRule(ctx context.Context, ruleID string, userID string) (model.Rule, error) {
+	var rule model.Rule
+	err := d.Client.Where("id = ? AND user_id = ?", ruleID, userID).First(&rule).Error
+	return rule, err
+}
+
+func (d *Database) GetSenderRules(ctx context.Context, userID string, aliasID string) ([]model.Rule, error) {
+	var rules []model.Rule
+	err := d.Client.Where("user_id = ? AND (alias_id = '' OR alias_id = ?)", userID, aliasID).Order("created_at asc").Find(&rules).Error
+	return rules, err
+}
+
+func (d *Database) PostRule(ctx context.Context, rule model.Rule) (model.Rule, error) {
+	err := d.Client.Create(&rule).Error
+	return rule, err
+}
+
+func (d *Database) UpdateRule(ctx context.Context, rule model.Rule) error {
+	return d.Client.Model(&rule).Where("user_id = ?", rule.UserID).Updates(map[string]any{
+		"alias_id":    rule.AliasID,
+		"pattern":     rule.Pattern,
+		"action":      rule.Action,
+		"description": rule.Description,
+	}).Error
+}
+
+func (d *Database) DeleteRule(ctx context.Context, ruleID string, userID string) error {
+	return d.Client.Where("id = ? AND user_id = ?", ruleID, userID).Delete(&model.Rule{}).Error
+}
+
+func (d *Database) DeleteRulesByUserID(ctx context.Context, userID string) error {
+	return d.Client.Where("user_id = ?", userID).Delete(&model.Rule{}).Error
+}
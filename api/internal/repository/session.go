@@ -46,6 +46,70 @@ func (d *Database) SaveSession(ctx context.Context, sessionData webauthn.Session
 	}).Error
 }
 
+// SaveUserSession saves a signed in session of the web app.
+func (d *Database) SaveUserSession(ctx context.Context, sessionData webauthn.SessionData, token string, userID string, ip string, userAgent string, exp time.Time) error {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return d.Client.Create(&model.Session{
+		UserID:     userID,
+		Token:      token,
+		Data:       data,
+		ExpiresAt:  exp,
+		SignedIn:   true,
+		IP:         ip,
+		UserAgent:  userAgent,
+		LastSeenAt: &now,
+	}).Error
+}
+
+// SaveAccessKeySession saves a session of the /v1/api group, authenticated
+// with an access key.
+func (d *Database) SaveAccessKeySession(ctx context.Context, sessionData webauthn.SessionData, token string, userID string, accessKeyID string, ip string, userAgent string, exp time.Time) error {
+	data, err := json.Marshal(sessionData)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return d.Client.Create(&model.Session{
+		UserID:      userID,
+		Token:       token,
+		Data:        data,
+		ExpiresAt:   exp,
+		AccessKeyID: accessKeyID,
+		SignedIn:    true,
+		IP:          ip,
+		UserAgent:   userAgent,
+		LastSeenAt:  &now,
+	}).Error
+}
+
+func (d *Database) GetSessions(ctx context.Context, userID string) ([]model.Session, error) {
+	var sessions []model.Session
+	err := d.Client.Where("user_id = ? AND signed_in = ? AND expires_at > ?", userID, true, time.Now()).Order("last_seen_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+func (d *Database) UpdateSessionLastSeen(ctx context.Context, ID string, at time.Time, ip string) error {
+	return d.Client.Model(&model.Session{}).Where("id = ?", ID).Updates(map[string]any{
+		"last_seen_at": at,
+		"ip":           ip,
+	}).Error
+}
+
+func (d *Database) DeleteSessionByID(ctx context.Context, ID string, userID string) error {
+	return d.Client.Where("id = ? AND user_id = ?", ID, userID).Delete(&model.Session{}).Error
+}
+
+// DeleteOtherSessions deletes the sessions of a user except exceptID.
+func (d *Database) DeleteOtherSessions(ctx context.Context, userID string, exceptID string) error {
+	return d.Client.Where("user_id = ? AND id <> ?", userID, exceptID).Delete(&model.Session{}).Error
+}
+
 func (d *Database) DeleteSession(ctx context.Context, token string) error {
 	return d.Client.Where("token = ?", token).Delete(&model.Session{}).Error
 }
@@ -29,6 +29,8 @@ func (d *Database) UpdateSettings(ctx context.Context, settings model.Settings)
 		"alias_format":  settings.AliasFormat,
 		"log_issues":    settings.LogIssues,
 		"remove_header": settings.RemoveHeader,
+		"spam_policy":   settings.SpamPolicy,
+		"quarantine":    settings.Quarantine,
 	}).Error
 }
 
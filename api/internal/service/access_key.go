@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,8 +26,17 @@ type AccessKeyStore interface {
 	PostAccessKey(context.Context, model.AccessKey) (model.AccessKey, error)
 	DeleteAccessKey(context.Context, string, string) error
 	DeleteAccessKeysByUserID(context.Context, string) error
+	GetAccessKeyUsage(context.Context, []string, time.Time) ([]model.AccessKeyUsage, error)
 }
 
+const (
+	// accessKeyUsageDays is the number of days of usage returned with keys
+	accessKeyUsageDays = 30
+
+	// accessKeyUsageTTL keeps usage in the cache if it is not flushed
+	accessKeyUsageTTL = 7 * 24 * time.Hour
+)
+
 func (s *Service) GetAccessKeys(ctx context.Context, userId string) ([]model.AccessKey, error) {
 	accessKeys, err := s.Store.GetAccessKeys(ctx, userId)
 	if err != nil {
@@ -34,6 +44,31 @@ func (s *Service) GetAccessKeys(ctx context.Context, userId string) ([]model.Acc
 		return nil, ErrGetAccessKeys
 	}
 
+	if len(accessKeys) == 0 {
+		return accessKeys, nil
+	}
+
+	ids := make([]string, 0, len(accessKeys))
+	for _, accessKey := range accessKeys {
+		ids = append(ids, accessKey.ID)
+	}
+
+	since := time.Now().UTC().AddDate(0, 0, -accessKeyUsageDays+1).Truncate(24 * time.Hour)
+	usage, err := s.Store.GetAccessKeyUsage(ctx, ids, since)
+	if err != nil {
+		log.Printf("error getting access key usage: %s", err.Error())
+		return nil, ErrGetAccessKeys
+	}
+
+	for i := range accessKeys {
+		accessKeys[i].Usage = []model.AccessKeyUsage{}
+		for _, day := range usage {
+			if day.AccessKeyID == accessKeys[i].ID {
+				accessKeys[i].Usage = append(accessKeys[i].Usage, day)
+			}
+		}
+	}
+
 	return accessKeys, nil
 }
 
@@ -59,6 +94,40 @@ func (s *Service) GetAccessKey(ctx context.Context, key string) (model.AccessKey
 	return accessKey, nil
 }
 
+// GetSessionAccessKey returns the access key a /v1/api session was
+// authenticated with, if it still exists and has not expired.
+func (s *Service) GetSessionAccessKey(ctx context.Context, id string) (model.AccessKey, error) {
+	accessKey, err := s.Store.GetAccessKey(ctx, id)
+	if err != nil {
+		return model.AccessKey{}, ErrGetAccessKey
+	}
+
+	if accessKey.IsExpired() {
+		return model.AccessKey{}, ErrAccessKeyExpired
+	}
+
+	return accessKey, nil
+}
+
+// RecordAccessKeyUsage counts a request made with an access key and keeps
+// the time and IP of its last use in the cache, jobs.FlushAccessKeyUsage
+// writes them to the database.
+func (s *Service) RecordAccessKeyUsage(ctx context.Context, accessKey model.AccessKey, ip string) {
+	now := time.Now().UTC()
+
+	key := model.AccessKeyRequestsKey + accessKey.ID + "_" + now.Format(time.DateOnly)
+	_, err := s.Cache.Incr(ctx, key, accessKeyUsageTTL)
+	if err != nil {
+		log.Printf("error counting access key request: %s", err.Error())
+	}
+
+	value := strconv.FormatInt(now.Unix(), 10) + " " + ip
+	err = s.Cache.Set(ctx, model.AccessKeyLastUsedKey+accessKey.ID, value, accessKeyUsageTTL)
+	if err != nil {
+		log.Printf("error setting access key last use: %s", err.Error())
+	}
+}
+
 func (s *Service) PostAccessKey(ctx context.Context, userId string, accessKey model.AccessKey) (model.AccessKey, error) {
 	if accessKey.TokenPlain != nil {
 		err := accessKey.SetToken(*accessKey.TokenPlain)
@@ -68,6 +137,9 @@ func (s *Service) PostAccessKey(ctx context.Context, userId string, accessKey mo
 		}
 	}
 
+	now := time.Now()
+	accessKey.TrackedSince = &now
+
 	return s.Store.PostAccessKey(ctx, accessKey)
 }
 
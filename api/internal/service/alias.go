@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	"ivpn.net/email/api/internal/model"
@@ -15,6 +16,8 @@ var (
 	ErrGetAliases           = errors.New("Unable to retrieve aliases.")
 	ErrGetAliasByName       = errors.New("alias not found:")
 	ErrDisabledAlias        = errors.New("alias disabled:")
+	ErrExpiredAlias         = errors.New("alias expired:")
+	ErrScheduledAlias       = errors.New("alias outside schedule:")
 	ErrDisabledDomain       = errors.New("domain disabled:")
 	ErrPostAlias            = errors.New("Unable to create alias. Please try again.")
 	ErrPostAliasLimit       = errors.New("You’ve reached the maximum number of allowed aliases.")
@@ -39,6 +42,10 @@ type AliasStore interface {
 	DeleteAliasByUserID(context.Context, string) error
 	DeleteAliasByDomain(context.Context, string, string) error
 	RestoreAlias(context.Context, string, string) error
+	PauseAlias(context.Context, string, time.Time) error
+	DisableAlias(context.Context, string) error
+	IncrementAliasReceived(context.Context, string) error
+	ThrottleAlias(context.Context, string, time.Time) error
 }
 
 // aliasDomainPart returns the domain portion of an alias name (e.g. "user@example.com" → "example.com").
@@ -245,6 +252,12 @@ func (s *Service) DeleteAlias(ctx context.Context, ID string, userID string) err
 		return ErrDeleteAlias
 	}
 
+	err = s.Store.DeleteContactsByAliasID(ctx, ID, userID)
+	if err != nil {
+		log.Printf("error deleting alias contacts: %s", err.Error())
+		return ErrDeleteAlias
+	}
+
 	return nil
 }
 
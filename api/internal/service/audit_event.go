@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetAuditEvents = errors.New("Unable to retrieve audit log.")
+)
+
+type AuditEventStore interface {
+	GetAuditEvents(context.Context, string, int, int) ([]model.AuditEvent, error)
+	GetAuditEventCount(context.Context, string) (int, error)
+	PostAuditEvent(context.Context, model.AuditEvent) error
+	DeleteAuditEventsByUserID(context.Context, string) error
+}
+
+func (s *Service) GetAuditEvents(ctx context.Context, userID string, limit int, page int) (model.AuditEventList, error) {
+	offset := (page - 1) * limit
+	if page < 1 {
+		offset = 0
+	}
+
+	events, err := s.Store.GetAuditEvents(ctx, userID, limit, offset)
+	if err != nil {
+		log.Printf("error getting audit events: %s", err.Error())
+		return model.AuditEventList{}, ErrGetAuditEvents
+	}
+
+	total, err := s.Store.GetAuditEventCount(ctx, userID)
+	if err != nil {
+		log.Printf("error getting audit event count: %s", err.Error())
+		return model.AuditEventList{}, ErrGetAuditEvents
+	}
+
+	return model.AuditEventList{Events: events, Total: total}, nil
+}
+
+// PostAuditEvent records an event in the audit log of a user. Failures are
+// logged only, they do not fail the audited action.
+func (s *Service) PostAuditEvent(ctx context.Context, event model.AuditEvent) {
+	event.UserAgent = truncateUserAgent(event.UserAgent)
+
+	err := s.Store.PostAuditEvent(ctx, event)
+	if err != nil {
+		log.Printf("error saving audit event: %s", err.Error())
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
+)
+
+var (
+	ErrGetContacts            = errors.New("Unable to retrieve contacts for this alias.")
+	ErrGetContact             = errors.New("Unable to retrieve contact.")
+	ErrPostContact            = errors.New("Unable to create contact.")
+	ErrUpdateContact          = errors.New("Unable to update contact. Please try again.")
+	ErrDeleteContactsByUserID = errors.New("Unable to delete contacts for this user.")
+)
+
+type ContactStore interface {
+	GetContacts(context.Context, string, string) ([]model.Contact, error)
+	GetContact(context.Context, string, string) (model.Contact, error)
+	GetContactByID(context.Context, string, string) (model.Contact, error)
+	GetContactByToken(context.Context, string) (model.Contact, error)
+	PostContact(context.Context, model.Contact) (bool, error)
+	SeeContact(context.Context, model.Contact, time.Time) error
+	UpdateContactBlocked(context.Context, string, string, bool) error
+	DeleteContactsByAliasID(context.Context, string, string) error
+	DeleteContactsByUserID(context.Context, string) error
+}
+
+func (s *Service) GetContacts(ctx context.Context, aliasID string, userID string) ([]model.Contact, error) {
+	alias, err := s.Store.GetAlias(ctx, aliasID, userID)
+	if err != nil {
+		log.Printf("error fetching alias: %s", err.Error())
+		return nil, ErrGetAlias
+	}
+
+	contacts, err := s.Store.GetContacts(ctx, aliasID, userID)
+	if err != nil {
+		log.Printf("error getting contacts: %s", err.Error())
+		return nil, ErrGetContacts
+	}
+
+	for i := range contacts {
+		contacts[i].ReplyTo = model.ContactAddress(alias.Name, contacts[i].Token)
+	}
+
+	return contacts, nil
+}
+
+// PostContact returns the contact of an address, created if needed, so the
+// user can compose a new message to it from the alias reply address.
+func (s *Service) PostContact(ctx context.Context, aliasID string, userID string, address string) (model.Contact, error) {
+	alias, err := s.Store.GetAlias(ctx, aliasID, userID)
+	if err != nil {
+		log.Printf("error fetching alias: %s", err.Error())
+		return model.Contact{}, ErrGetAlias
+	}
+
+	contact, err := s.getOrPostContact(ctx, alias, address)
+	if err != nil {
+		return model.Contact{}, err
+	}
+	contact.ReplyTo = model.ContactAddress(alias.Name, contact.Token)
+
+	return contact, nil
+}
+
+// ReplyAddress returns the reply address of a correspondent of an alias,
+// creating the contact on first use. It implements mailer.Contacts.
+func (s *Service) ReplyAddress(alias model.Alias, address string) (string, error) {
+	contact, err := s.getOrPostContact(context.Background(), alias, address)
+	if err != nil {
+		return "", err
+	}
+
+	return model.ContactAddress(alias.Name, contact.Token), nil
+}
+
+// ResolveReplyAddress returns the correspondent a reply address with a
+// contact token stands for. It implements mailer.Contacts.
+func (s *Service) ResolveReplyAddress(address string) (string, bool) {
+	_, contact, ok := s.findContact(address)
+	if !ok {
+		return "", false
+	}
+
+	return contact.Address, true
+}
+
+func (s *Service) UpdateContactBlocked(ctx context.Context, aliasID string, contactID string, userID string, blocked bool) error {
+	contact, err := s.Store.GetContactByID(ctx, contactID, userID)
+	if err != nil || contact.AliasID != aliasID {
+		return ErrGetContact
+	}
+
+	err = s.Store.UpdateContactBlocked(ctx, contactID, userID, blocked)
+	if err != nil {
+		log.Printf("error updating contact: %s", err.Error())
+		return ErrUpdateContact
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteContactsByUserID(ctx context.Context, userID string) error {
+	err := s.Store.DeleteContactsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting contacts by user ID: %s", err.Error())
+		return ErrDeleteContactsByUserID
+	}
+
+	return nil
+}
+
+func (s *Service) getOrPostContact(ctx context.Context, alias model.Alias, address string) (model.Contact, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+
+	contact, err := s.Store.GetContact(ctx, alias.Name, address)
+	if err == nil {
+		return contact, nil
+	}
+
+	token, err := model.NewContactToken()
+	if err != nil {
+		log.Printf("error generating contact token: %s", err.Error())
+		return model.Contact{}, ErrPostContact
+	}
+
+	_, err = s.Store.PostContact(ctx, model.Contact{
+		UserID:    alias.UserID,
+		AliasID:   alias.ID,
+		AliasName: alias.Name,
+		Address:   address,
+		Token:     token,
+	})
+	if err != nil {
+		log.Printf("error creating contact: %s", err.Error())
+		return model.Contact{}, ErrPostContact
+	}
+
+	// Created here or concurrently by another delivery
+	contact, err = s.Store.GetContact(ctx, alias.Name, address)
+	if err != nil {
+		log.Printf("error getting contact: %s", err.Error())
+		return model.Contact{}, ErrGetContact
+	}
+
+	return contact, nil
+}
+
+// seeContact records a message forwarded from a correspondent of an alias.
+func (s *Service) seeContact(alias model.Alias, from string) {
+	if utils.ValidateEmail(from) != nil {
+		return
+	}
+
+	token, err := model.NewContactToken()
+	if err != nil {
+		log.Printf("error generating contact token: %s", err.Error())
+		return
+	}
+
+	err = s.Store.SeeContact(context.Background(), model.Contact{
+		UserID:    alias.UserID,
+		AliasID:   alias.ID,
+		AliasName: alias.Name,
+		Address:   strings.ToLower(strings.TrimSpace(from)),
+		Token:     token,
+	}, time.Now())
+	if err != nil {
+		log.Printf("error saving contact: %s", err.Error())
+	}
+}
+
+// findContact looks up the contact of a reply address with a token. The
+// alias part must be the alias of the contact, so tokens cannot be moved to
+// another alias.
+func (s *Service) findContact(to string) (string, model.Contact, bool) {
+	aliasName, token, ok := model.ParseContactAddress(to)
+	if !ok {
+		return "", model.Contact{}, false
+	}
+
+	contact, err := s.Store.GetContactByToken(context.Background(), token)
+	if err != nil || !strings.EqualFold(contact.AliasName, aliasName) {
+		return "", model.Contact{}, false
+	}
+
+	return contact.AliasName, contact, true
+}
+
+// parseReplyAddress returns the alias name and the correspondent of a reply
+// address, with a contact token or in the legacy alias+john=example.com
+// format. The correspondent is empty for other addresses.
+func (s *Service) parseReplyAddress(to string) (string, string) {
+	if aliasName, contact, ok := s.findContact(to); ok {
+		return aliasName, contact.Address
+	}
+
+	return model.ParseReplyTo(to)
+}
+
+// blockedContactError is returned for a sender the user blocked as a contact
+// of the alias, so ProcessMessage records the blocked message.
+type blockedContactError struct{}
+
+func (e blockedContactError) Error() string {
+	return ErrBlockedSender.Error() + " contact"
+}
+
+func (e blockedContactError) Unwrap() error {
+	return ErrBlockedSender
+}
+
+// checkContact blocks mail from contacts the user blocked on this alias.
+func (s *Service) checkContact(from string, alias model.Alias) error {
+	if utils.ValidateEmail(from) != nil {
+		return nil
+	}
+
+	contact, err := s.Store.GetContact(context.Background(), alias.Name, strings.ToLower(from))
+	if err != nil || !contact.Blocked {
+		return nil
+	}
+
+	return blockedContactError{}
+}
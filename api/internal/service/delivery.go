@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"ivpn.net/email/api/internal/client/mailer"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrQueueDelivery = errors.New("Unable to queue message for delivery.")
+)
+
+type DeliveryStore interface {
+	PostDelivery(context.Context, model.Delivery) (bool, error)
+	ClaimDeliveries(context.Context, int, time.Duration) ([]model.Delivery, error)
+	UpdateDelivery(context.Context, model.Delivery) error
+}
+
+// EnqueueDelivery persists a delivery, due now unless NextAttemptAt is set.
+// Deliveries with an idempotency key that is already queued (or delivered) are
+// skipped.
+func (s *Service) EnqueueDelivery(ctx context.Context, delivery model.Delivery) error {
+	delivery.Status = model.DeliveryPending
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = time.Now()
+	}
+
+	queued, err := s.Store.PostDelivery(ctx, delivery)
+	if err != nil {
+		log.Printf("error queueing delivery: %s", err.Error())
+		return ErrQueueDelivery
+	}
+
+	if !queued {
+		log.Printf("delivery already queued, skipping [key: %s]", delivery.IdempotencyKey)
+		return nil
+	}
+
+	// Wake up an idle worker
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// StartDeliveryWorkers starts the pool of workers processing queued deliveries.
+func (s *Service) StartDeliveryWorkers(ctx context.Context) {
+	workers := s.Cfg.Service.QueueWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	for range workers {
+		go s.deliveryWorker(ctx)
+	}
+
+	log.Printf("Delivery workers started: %d", workers)
+}
+
+func (s *Service) deliveryWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.Cfg.Service.QueuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain due deliveries before waiting again
+		for s.processDeliveries(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *Service) processDeliveries(ctx context.Context) bool {
+	deliveries, err := s.Store.ClaimDeliveries(ctx, 1, s.Cfg.Service.QueueLease)
+	if err != nil {
+		log.Printf("error claiming deliveries: %s", err.Error())
+		return false
+	}
+
+	for _, delivery := range deliveries {
+		s.DeliverMessage(ctx, delivery)
+	}
+
+	return len(deliveries) > 0
+}
+
+// DeliverMessage attempts a single queued delivery and reschedules it with
+// exponential backoff on failure. Deliveries exceeding the max attempts, or
+// failing permanently (rejected with 5xx, or the alias or recipient no longer
+// exists), are dead-lettered as FailedDelivery logs.
+func (s *Service) DeliverMessage(ctx context.Context, delivery model.Delivery) {
+	alias, settings, rcp, err := s.loadDelivery(ctx, delivery)
+	if err == nil {
+		err = s.sendMessage(delivery.From, delivery.FromName, rcp, delivery.Data, alias, delivery.Type, settings, delivery.Filter)
+	}
+
+	if err == nil {
+		delivery.Status = model.DeliverySent
+		delivery.Attempts++
+		delivery.LastError = ""
+		delivery.Data = nil
+		delivery.LockedUntil = nil
+		delivery.LockToken = ""
+		if err := s.Store.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("error updating delivery: %s", err.Error())
+		}
+
+		if err := s.SaveMessage(ctx, alias, delivery.Type); err != nil {
+			log.Println("error saving message", err)
+		}
+
+		return
+	}
+
+	log.Println("error delivering message [alias:", alias.Name, "]:", err)
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+	delivery.LockedUntil = nil
+	delivery.LockToken = ""
+
+	if alias.ID == "" {
+		alias.ID = delivery.AliasID
+		alias.UserID = delivery.UserID
+	}
+
+	permanent := mailer.IsPermanent(err) || errors.Is(err, gorm.ErrRecordNotFound)
+	if permanent || delivery.Attempts >= s.Cfg.Service.QueueMaxAttempts {
+		if delivery.Type == model.Forward {
+			s.releaseDedupeKey(model.DedupeKey(model.ParseMessageID(delivery.Data), delivery.Data, delivery.AliasID, delivery.Recipient))
+		}
+
+		if settings.LogIssues {
+			err := s.ProcessFailedDeliveryLog(alias, delivery)
+			if err != nil {
+				log.Println("error processing failed delivery log", err)
+			}
+		}
+
+		delivery.Status = model.DeliveryFailed
+		delivery.Data = nil
+		if err := s.Store.UpdateDelivery(ctx, delivery); err != nil {
+			log.Printf("error updating delivery: %s", err.Error())
+		}
+
+		return
+	}
+
+	// Log the first deferral only, retries are not logged individually
+	if delivery.Attempts == 1 && settings.LogIssues {
+		err := s.ProcessDiagnosticLog(alias, delivery.From, delivery.Recipient, delivery.LastError, model.DeferredDelivery)
+		if err != nil {
+			log.Println("error processing diagnostic log", err)
+		}
+	}
+
+	delivery.NextAttemptAt = time.Now().Add(model.DeliveryBackoff(delivery.Attempts, s.Cfg.Service.QueueBackoffBase, s.Cfg.Service.QueueBackoffMax))
+	if err := s.Store.UpdateDelivery(ctx, delivery); err != nil {
+		log.Printf("error updating delivery: %s", err.Error())
+	}
+}
+
+func (s *Service) loadDelivery(ctx context.Context, delivery model.Delivery) (model.Alias, model.Settings, model.Recipient, error) {
+	alias, err := s.Store.GetAlias(ctx, delivery.AliasID, delivery.UserID)
+	if err != nil {
+		return model.Alias{}, model.Settings{}, model.Recipient{}, err
+	}
+
+	settings, err := s.GetSettings(ctx, delivery.UserID)
+	if err != nil {
+		return alias, model.Settings{}, model.Recipient{}, err
+	}
+
+	// Reply | Send go to the external address, forwards to a verified recipient
+	rcp := model.Recipient{Email: delivery.Recipient}
+	if delivery.Type == model.Forward {
+		rcp, err = s.Store.GetRecipientByEmail(ctx, delivery.Recipient, delivery.UserID)
+		if err != nil {
+			return alias, settings, model.Recipient{}, err
+		}
+	}
+
+	return alias, settings, rcp, nil
+}
+
+// ProcessFailedDeliveryLog dead-letters a delivery, keeping the raw message
+// so it can be downloaded from the log.
+func (s *Service) ProcessFailedDeliveryLog(alias model.Alias, delivery model.Delivery) error {
+	lg := model.Log{
+		ID:          uuid.New().String(),
+		CreatedAt:   time.Now(),
+		AttemptedAt: delivery.CreatedAt,
+		Type:        model.FailedDelivery,
+		UserID:      alias.UserID,
+		AliasID:     alias.ID,
+		From:        delivery.From,
+		Destination: delivery.Recipient,
+		Message:     delivery.LastError,
+	}
+
+	err := s.SaveLogToFile(context.Background(), lg.ID, delivery.Data)
+	if err != nil {
+		return err
+	}
+
+	return s.PostLog(context.Background(), lg)
+}
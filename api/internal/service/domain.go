@@ -19,6 +19,7 @@ var (
 	ErrGetDomain             = errors.New("Unable to retrieve domain.")
 	ErrGetDomainsCount       = errors.New("Unable to retrieve domains count.")
 	ErrGetDNSConfig          = errors.New("Unable to retrieve DNS config.")
+	ErrGetDKIMRecords        = errors.New("Unable to retrieve DKIM records.")
 	ErrPostDomain            = errors.New("Unable to create domain. Please try again.")
 	ErrPostDomainPredefined  = errors.New("Please enter a different domain.")
 	ErrPostDomainInactiveSub = errors.New("Unable to create domain. Subscription is not active.")
@@ -119,6 +120,14 @@ func (s *Service) GetDNSConfig(ctx context.Context, userId string) (model.DNSCon
 
 	domain := domains[0]
 	dkim := strings.Split(s.Cfg.SMTPClient.DkimSelector, ",")
+	if s.Cfg.SMTPClient.DkimSelector == "" {
+		dkim = []string{}
+		for _, key := range s.Mailer.DKIMKeys() {
+			if key.Domain == domain {
+				dkim = append(dkim, key.Selector)
+			}
+		}
+	}
 	hosts := strings.Split(s.Cfg.SMTPClient.Host, ",")
 
 	dnsConfig := model.DNSConfig{
@@ -131,6 +140,31 @@ func (s *Service) GetDNSConfig(ctx context.Context, userId string) (model.DNSCon
 	return dnsConfig, nil
 }
 
+// GetDKIMRecords returns the TXT records of the DKIM keys signing mail of
+// the service domains, and of custom domains through their CNAME records.
+func (s *Service) GetDKIMRecords(ctx context.Context) ([]model.DKIMRecord, error) {
+	records := []model.DKIMRecord{}
+	signing := map[string]bool{}
+	for _, key := range s.Mailer.DKIMKeys() {
+		value, err := key.TXTRecord()
+		if err != nil {
+			log.Printf("error getting DKIM record: %s", err.Error())
+			return nil, ErrGetDKIMRecords
+		}
+
+		records = append(records, model.DKIMRecord{
+			Domain:   key.Domain,
+			Selector: key.Selector,
+			Name:     key.Name(),
+			Value:    value,
+			Active:   !signing[key.Domain],
+		})
+		signing[key.Domain] = true
+	}
+
+	return records, nil
+}
+
 func (s *Service) GetOwnerVerifyRecordNewDomain(ctx context.Context, userId string) (string, error) {
 	count, err := s.GetDomainsCount(ctx, userId)
 	if err != nil {
@@ -221,6 +255,14 @@ func (s *Service) DeleteDomain(ctx context.Context, domainID string, userID stri
 		return ErrDeleteAliasByDomain
 	}
 
+	// Delete the generated DKIM keys
+	err = s.Store.DeleteDomainKeysByDomainID(ctx, domainID, userID)
+	if err != nil {
+		log.Printf("error deleting domain keys: %s", err.Error())
+		return ErrDeleteDomain
+	}
+	s.keys.delete(domain.Name)
+
 	// Delete the domain
 	err = s.Store.DeleteDomain(ctx, domainID, userID)
 	if err != nil {
@@ -401,7 +443,10 @@ func (s *Service) VerifyDomainSend(ctx context.Context, domain string, userID st
 		return ErrDNSLookupSPF
 	}
 
-	// DKIM records
+	// DKIM records, a published generated key replaces the delegated selectors
+	if s.verifyDomainKeys(ctx, domain) {
+		dnsConfig.DKIM = nil
+	}
 	for _, selector := range dnsConfig.DKIM {
 		ok, err := utils.LookupCNAME(selector+"._domainkey."+domain, selector+"._domainkey."+dnsConfig.Domain)
 		if err != nil {
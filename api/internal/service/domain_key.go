@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
+)
+
+var (
+	ErrGetDomainKeys       = errors.New("Unable to retrieve DKIM keys for this domain.")
+	ErrPostDomainKey       = errors.New("Unable to generate DKIM key. Please try again.")
+	ErrDomainKeyExists     = errors.New("A DKIM key already exists for this domain. Please rotate it instead.")
+	ErrDomainKeyNotFound   = errors.New("There is no DKIM key to rotate for this domain. Please generate one first.")
+	ErrDomainKeyDisabled   = errors.New("DKIM key generation is not available.")
+	ErrDeleteDomainKeys    = errors.New("Unable to delete DKIM keys. Please try again.")
+	ErrInvalidKeyAlgorithm = errors.New("Please select a valid DKIM key algorithm (rsa or ed25519).")
+)
+
+// domainKeyTTL is how long custom domain keys are cached for signing.
+const domainKeyTTL = time.Minute
+
+type DomainKeyStore interface {
+	GetDomainKeys(context.Context, string, string) ([]model.DomainKey, error)
+	GetDomainKeysByName(context.Context, string) ([]model.DomainKey, error)
+	PostDomainKey(context.Context, model.DomainKey) (model.DomainKey, error)
+	UpdateDomainKeyStatus(context.Context, string, model.DomainKeyStatus) error
+	DeleteDomainKey(context.Context, string) error
+	DeleteDomainKeysByDomainID(context.Context, string, string) error
+	DeleteDomainKeysByUserID(context.Context, string) error
+}
+
+func (s *Service) GetDomainKeys(ctx context.Context, domainID string, userID string) ([]model.DomainKey, error) {
+	domain, err := s.GetDomain(ctx, domainID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.Store.GetDomainKeys(ctx, domainID, userID)
+	if err != nil {
+		log.Printf("error getting domain keys: %s", err.Error())
+		return nil, ErrGetDomainKeys
+	}
+
+	for i := range keys {
+		keys[i].Name = keys[i].Selector + "._domainkey." + domain.Name
+	}
+
+	return keys, nil
+}
+
+// GenerateDomainKey generates the first DKIM key of a domain. The key is
+// pending until its TXT record is found by VerifyDomainDNSRecords.
+func (s *Service) GenerateDomainKey(ctx context.Context, domainID string, userID string, algorithm string) (model.DomainKey, error) {
+	domain, err := s.GetDomain(ctx, domainID, userID)
+	if err != nil {
+		return model.DomainKey{}, err
+	}
+
+	keys, err := s.Store.GetDomainKeys(ctx, domainID, userID)
+	if err != nil {
+		log.Printf("error getting domain keys: %s", err.Error())
+		return model.DomainKey{}, ErrGetDomainKeys
+	}
+
+	if len(keys) > 0 {
+		return model.DomainKey{}, ErrDomainKeyExists
+	}
+
+	return s.postDomainKey(ctx, domain, algorithm, keys)
+}
+
+// RotateDomainKey generates a new pending key for a domain. The current key
+// keeps signing until the new TXT record is found, and is retired then.
+// Pending and retired keys of a previous rotation are deleted.
+func (s *Service) RotateDomainKey(ctx context.Context, domainID string, userID string, algorithm string) (model.DomainKey, error) {
+	domain, err := s.GetDomain(ctx, domainID, userID)
+	if err != nil {
+		return model.DomainKey{}, err
+	}
+
+	keys, err := s.Store.GetDomainKeys(ctx, domainID, userID)
+	if err != nil {
+		log.Printf("error getting domain keys: %s", err.Error())
+		return model.DomainKey{}, ErrGetDomainKeys
+	}
+
+	if len(keys) == 0 {
+		return model.DomainKey{}, ErrDomainKeyNotFound
+	}
+
+	for _, key := range keys {
+		if key.Status == model.DomainKeyActive {
+			continue
+		}
+
+		err := s.Store.DeleteDomainKey(ctx, key.ID)
+		if err != nil {
+			log.Printf("error deleting domain key: %s", err.Error())
+			return model.DomainKey{}, ErrPostDomainKey
+		}
+	}
+
+	return s.postDomainKey(ctx, domain, algorithm, keys)
+}
+
+func (s *Service) DeleteDomainKeysByUserID(ctx context.Context, userID string) error {
+	err := s.Store.DeleteDomainKeysByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting domain keys by user ID: %s", err.Error())
+		return ErrDeleteDomainKeys
+	}
+
+	return nil
+}
+
+func (s *Service) postDomainKey(ctx context.Context, domain model.Domain, algorithm string, keys []model.DomainKey) (model.DomainKey, error) {
+	if s.Cfg.SMTPClient.DKIMSecret == "" {
+		return model.DomainKey{}, ErrDomainKeyDisabled
+	}
+
+	if algorithm == "" {
+		algorithm = "rsa"
+	}
+
+	data, err := utils.GenerateDKIMKey(algorithm)
+	if err != nil {
+		if errors.Is(err, utils.ErrInvalidDKIMAlgorithm) {
+			return model.DomainKey{}, ErrInvalidKeyAlgorithm
+		}
+		log.Printf("error generating domain key: %s", err.Error())
+		return model.DomainKey{}, ErrPostDomainKey
+	}
+
+	signer, err := utils.ParseDKIMKey(data)
+	if err != nil {
+		log.Printf("error generating domain key: %s", err.Error())
+		return model.DomainKey{}, ErrPostDomainKey
+	}
+
+	value, err := utils.DKIMKey{Signer: signer}.TXTRecord()
+	if err != nil {
+		log.Printf("error generating domain key: %s", err.Error())
+		return model.DomainKey{}, ErrPostDomainKey
+	}
+
+	encrypted, err := utils.Encrypt(s.Cfg.SMTPClient.DKIMSecret, data)
+	if err != nil {
+		log.Printf("error encrypting domain key: %s", err.Error())
+		return model.DomainKey{}, ErrPostDomainKey
+	}
+
+	selector := model.NewDomainKeySelector(time.Now())
+	for _, key := range keys {
+		if key.Selector == selector {
+			selector += "b"
+		}
+	}
+
+	key, err := s.Store.PostDomainKey(ctx, model.DomainKey{
+		UserID:     domain.UserID,
+		DomainID:   domain.ID,
+		Selector:   selector,
+		Algorithm:  algorithm,
+		PrivateKey: encrypted,
+		Value:      value,
+		Status:     model.DomainKeyPending,
+	})
+	if err != nil {
+		log.Printf("error creating domain key: %s", err.Error())
+		return model.DomainKey{}, ErrPostDomainKey
+	}
+	key.Name = key.Selector + "._domainkey." + domain.Name
+
+	return key, nil
+}
+
+// verifyDomainKeys reports whether the TXT record of a generated key of the
+// domain is published. A pending key found in DNS becomes active, and the
+// key it replaces is retired.
+func (s *Service) verifyDomainKeys(ctx context.Context, domainName string) bool {
+	keys, err := s.Store.GetDomainKeysByName(ctx, domainName)
+	if err != nil {
+		log.Printf("error getting domain keys: %s", err.Error())
+		return false
+	}
+
+	verified := false
+	for _, key := range keys {
+		if key.Status == model.DomainKeyRetired {
+			continue
+		}
+
+		ok, err := utils.LookupTXTExact(key.Selector+"._domainkey."+domainName, key.Value)
+		if err != nil {
+			log.Printf("error looking up TXT record for domain key %s: %s", key.Selector, err.Error())
+			continue
+		}
+		if !ok {
+			continue
+		}
+		verified = true
+
+		if key.Status == model.DomainKeyPending {
+			s.activateDomainKey(ctx, key, keys)
+			s.keys.delete(domainName)
+		}
+		break
+	}
+
+	return verified
+}
+
+func (s *Service) activateDomainKey(ctx context.Context, key model.DomainKey, keys []model.DomainKey) {
+	err := s.Store.UpdateDomainKeyStatus(ctx, key.ID, model.DomainKeyActive)
+	if err != nil {
+		log.Printf("error activating domain key: %s", err.Error())
+		return
+	}
+
+	for _, k := range keys {
+		if k.ID == key.ID || k.Status != model.DomainKeyActive {
+			continue
+		}
+
+		err := s.Store.UpdateDomainKeyStatus(ctx, k.ID, model.DomainKeyRetired)
+		if err != nil {
+			log.Printf("error retiring domain key: %s", err.Error())
+		}
+	}
+}
+
+// DomainKey returns the active generated DKIM key of a custom domain, it
+// implements mailer.Keyring.
+func (s *Service) DomainKey(domain string) (utils.DKIMKey, bool) {
+	if s.Cfg.SMTPClient.DKIMSecret == "" {
+		return utils.DKIMKey{}, false
+	}
+
+	domain = strings.ToLower(domain)
+	if key, ok, found := s.keys.get(domain); found {
+		return key, ok
+	}
+
+	key, ok := s.loadDomainKey(domain)
+	s.keys.set(domain, key, ok)
+
+	return key, ok
+}
+
+func (s *Service) loadDomainKey(domain string) (utils.DKIMKey, bool) {
+	keys, err := s.Store.GetDomainKeysByName(context.Background(), domain)
+	if err != nil {
+		log.Printf("error getting domain keys: %s", err.Error())
+		return utils.DKIMKey{}, false
+	}
+
+	for _, key := range keys {
+		if key.Status != model.DomainKeyActive {
+			continue
+		}
+
+		data, err := utils.Decrypt(s.Cfg.SMTPClient.DKIMSecret, key.PrivateKey)
+		if err != nil {
+			log.Printf("error decrypting domain key %s: %s", key.Selector, err.Error())
+			return utils.DKIMKey{}, false
+		}
+
+		signer, err := utils.ParseDKIMKey(data)
+		if err != nil {
+			log.Printf("error parsing domain key %s: %s", key.Selector, err.Error())
+			return utils.DKIMKey{}, false
+		}
+
+		return utils.DKIMKey{Domain: domain, Selector: key.Selector, Signer: signer}, true
+	}
+
+	return utils.DKIMKey{}, false
+}
+
+type cachedDomainKey struct {
+	key     utils.DKIMKey
+	ok      bool
+	expires time.Time
+}
+
+// domainKeyCache keeps decrypted keys in memory, so messages are not signed
+// with a database lookup each.
+type domainKeyCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedDomainKey
+}
+
+func newDomainKeyCache() *domainKeyCache {
+	return &domainKeyCache{entries: map[string]cachedDomainKey{}}
+}
+
+func (c *domainKeyCache) get(domain string) (utils.DKIMKey, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, found := c.entries[domain]
+	if !found || time.Now().After(entry.expires) {
+		delete(c.entries, domain)
+		return utils.DKIMKey{}, false, false
+	}
+
+	return entry.key, entry.ok, true
+}
+
+func (c *domainKeyCache) set(domain string, key utils.DKIMKey, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[domain] = cachedDomainKey{key: key, ok: ok, expires: time.Now().Add(domainKeyTTL)}
+}
+
+func (c *domainKeyCache) delete(domain string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, strings.ToLower(domain))
+}
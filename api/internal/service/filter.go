@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetFilters      = errors.New("Unable to retrieve filters.")
+	ErrGetFilter       = errors.New("Unable to retrieve filter.")
+	ErrPostFilter      = errors.New("Unable to create filter. Please try again.")
+	ErrUpdateFilter    = errors.New("Unable to update filter. Please try again.")
+	ErrDeleteFilter    = errors.New("Unable to delete filter. Please try again.")
+	ErrInvalidFilter   = errors.New("Please check the filter conditions and actions.")
+	ErrFilterAlias     = errors.New("Unable to find the alias for this filter.")
+	ErrFilterRecipient = errors.New("Filter recipient must be a verified recipient.")
+	ErrDryRunFilters   = errors.New("Unable to test filters against this message.")
+	ErrImportFilters   = errors.New("Unable to import filters. Please try again.")
+	ErrFilteredMessage = errors.New("message dropped by filter:")
+)
+
+type FilterStore interface {
+	GetFilters(context.Context, string) ([]model.Filter, error)
+	GetFilter(context.Context, string, string) (model.Filter, error)
+	GetAliasFilters(context.Context, string, string) ([]model.Filter, error)
+	PostFilter(context.Context, model.Filter) (model.Filter, error)
+	UpdateFilter(context.Context, model.Filter) error
+	DeleteFilter(context.Context, string, string) error
+	DeleteFiltersByUserID(context.Context, string) error
+}
+
+func (s *Service) GetFilters(ctx context.Context, userID string) ([]model.Filter, error) {
+	filters, err := s.Store.GetFilters(ctx, userID)
+	if err != nil {
+		log.Printf("error getting filters: %s", err.Error())
+		return nil, ErrGetFilters
+	}
+
+	return filters, nil
+}
+
+func (s *Service) GetFilter(ctx context.Context, filterID string, userID string) (model.Filter, error) {
+	filter, err := s.Store.GetFilter(ctx, filterID, userID)
+	if err != nil {
+		log.Printf("error getting filter: %s", err.Error())
+		return model.Filter{}, ErrGetFilter
+	}
+
+	return filter, nil
+}
+
+func (s *Service) PostFilter(ctx context.Context, filter model.Filter) (model.Filter, error) {
+	if err := s.validateFilter(ctx, filter); err != nil {
+		return model.Filter{}, err
+	}
+
+	created, err := s.Store.PostFilter(ctx, filter)
+	if err != nil {
+		log.Printf("error creating filter: %s", err.Error())
+		return model.Filter{}, ErrPostFilter
+	}
+
+	return created, nil
+}
+
+func (s *Service) UpdateFilter(ctx context.Context, filter model.Filter) error {
+	if err := s.validateFilter(ctx, filter); err != nil {
+		return err
+	}
+
+	err := s.Store.UpdateFilter(ctx, filter)
+	if err != nil {
+		log.Printf("error updating filter: %s", err.Error())
+		return ErrUpdateFilter
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteFilter(ctx context.Context, filterID string, userID string) error {
+	err := s.Store.DeleteFilter(ctx, filterID, userID)
+	if err != nil {
+		log.Printf("error deleting filter: %s", err.Error())
+		return ErrDeleteFilter
+	}
+
+	return nil
+}
+
+// DryRunFilters evaluates filters against a message stored with a log entry
+// without sending anything. When filter is nil the saved filters of the alias
+// (or the account filters when aliasID is empty) are evaluated.
+func (s *Service) DryRunFilters(ctx context.Context, userID string, logID string, aliasID string, filter *model.Filter) (model.FilterResult, error) {
+	data, err := s.GetLogFile(ctx, logID, userID)
+	if err != nil {
+		return model.FilterResult{}, ErrDryRunFilters
+	}
+
+	msg, err := model.ParseMsg(data)
+	if err != nil {
+		log.Printf("error parsing message for filter dry-run: %s", err.Error())
+		return model.FilterResult{}, ErrDryRunFilters
+	}
+
+	var filters []model.Filter
+	if filter != nil {
+		filter.UserID = userID
+		filter.AliasID = aliasID
+		filter.Enabled = true
+		if err := s.validateFilter(ctx, *filter); err != nil {
+			return model.FilterResult{}, err
+		}
+		filters = []model.Filter{*filter}
+	} else {
+		filters, err = s.Store.GetAliasFilters(ctx, userID, aliasID)
+		if err != nil {
+			log.Printf("error getting filters: %s", err.Error())
+			return model.FilterResult{}, ErrGetFilters
+		}
+	}
+
+	return model.EvalFilters(model.SortFilters(filters), aliasID, msg), nil
+}
+
+// ImportFilters compiles a Sieve script into filters of the alias. Filters
+// are appended after the existing ones; constructs that can't be imported
+// are reported by line.
+func (s *Service) ImportFilters(ctx context.Context, userID string, aliasID string, script string) (model.SieveImport, error) {
+	_, err := s.Store.GetAlias(ctx, aliasID, userID)
+	if err != nil {
+		log.Printf("error getting filter alias: %s", err.Error())
+		return model.SieveImport{}, ErrFilterAlias
+	}
+
+	existing, err := s.Store.GetAliasFilters(ctx, userID, aliasID)
+	if err != nil {
+		log.Printf("error getting filters: %s", err.Error())
+		return model.SieveImport{}, ErrImportFilters
+	}
+
+	position := 0
+	for _, f := range existing {
+		if f.AliasID == aliasID && f.Position >= position {
+			position = f.Position + 1
+		}
+	}
+
+	rules, errs := model.ParseSieve(script)
+	res := model.SieveImport{Errors: errs}
+	for _, rule := range rules {
+		filter := rule.Filter
+		filter.UserID = userID
+		filter.AliasID = aliasID
+		filter.Position = position
+
+		if err := s.validateFilter(ctx, filter); err != nil {
+			res.Errors = append(res.Errors, model.SieveError{Line: rule.Line, Message: err.Error()})
+			continue
+		}
+
+		_, err := s.Store.PostFilter(ctx, filter)
+		if err != nil {
+			log.Printf("error importing filter: %s", err.Error())
+			return res, ErrImportFilters
+		}
+
+		res.Imported++
+		position++
+	}
+
+	sort.SliceStable(res.Errors, func(i, j int) bool {
+		return res.Errors[i].Line < res.Errors[j].Line
+	})
+
+	return res, nil
+}
+
+func (s *Service) validateFilter(ctx context.Context, filter model.Filter) error {
+	if err := filter.Validate(); err != nil {
+		return ErrInvalidFilter
+	}
+
+	if filter.AliasID != "" {
+		_, err := s.Store.GetAlias(ctx, filter.AliasID, filter.UserID)
+		if err != nil {
+			log.Printf("error getting filter alias: %s", err.Error())
+			return ErrFilterAlias
+		}
+	}
+
+	for _, a := range filter.Actions {
+		if a.Type != model.FilterForwardTo {
+			continue
+		}
+
+		rcps, err := s.GetVerifiedRecipients(ctx, a.Value, filter.UserID)
+		if err != nil || len(rcps) == 0 {
+			return ErrFilterRecipient
+		}
+	}
+
+	return nil
+}
+
+// getFilters returns the filters of the alias and the account in evaluation
+// order. Filters are not applied if they can't be loaded, so a database error
+// never drops mail.
+func (s *Service) getFilters(alias model.Alias) []model.Filter {
+	filters, err := s.Store.GetAliasFilters(context.Background(), alias.UserID, alias.ID)
+	if err != nil {
+		log.Printf("error getting filters: %s", err.Error())
+		return nil
+	}
+
+	return model.SortFilters(filters)
+}
+
+// applyFilters evaluates the filters deciding whether and where the message is
+// forwarded. The result is queued with each copy, so subject tags and spam
+// flags are applied at delivery without evaluating the filters again.
+func (s *Service) applyFilters(msg model.Msg, alias model.Alias, rcps []model.Recipient) ([]model.Recipient, model.FilterResult, error) {
+	res := model.EvalFilters(s.getFilters(alias), alias.ID, msg)
+
+	if res.Drop {
+		if err := s.SaveFilteredMessage(context.Background(), alias, res.DropID); err != nil {
+			log.Println("error saving message", err)
+		}
+		return nil, res, ErrFilteredMessage
+	}
+
+	if res.ForwardTo != "" {
+		filtered, err := s.GetVerifiedRecipients(context.Background(), res.ForwardTo, alias.UserID)
+		if err != nil || len(filtered) == 0 {
+			log.Println("filter recipient is not verified, forwarding to alias recipients [alias:", alias.Name, "]")
+			return rcps, res, nil
+		}
+		return filtered, res, nil
+	}
+
+	return rcps, res, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"slices"
+	"time"
 
 	"ivpn.net/email/api/internal/model"
 )
@@ -15,6 +16,7 @@ var (
 	ErrGetMessagesByAlias    = errors.New("Unable to retrieve messages for this alias.")
 	ErrPostMessage           = errors.New("Unable to create message.")
 	ErrDeleteMessageByUserID = errors.New("Unable to delete messages for this user.")
+	ErrDailySendReplyLimit   = errors.New("Daily send and reply limit reached.")
 )
 
 type MessageStore interface {
@@ -24,6 +26,7 @@ type MessageStore interface {
 	DeleteMessageByUserID(context.Context, string) error
 	DeleteMessage(context.Context, uint, string) error
 	SendReplyDailyCount(context.Context, string) (int, error)
+	GetMessageCounts(context.Context, string, string, time.Time, time.Time) ([]model.MessageCount, error)
 }
 
 func (s *Service) GetMessagesByUser(ctx context.Context, userID string) ([]model.Message, error) {
@@ -61,6 +64,40 @@ func (s *Service) SaveMessage(ctx context.Context, alias model.Alias, msgType mo
 	return nil
 }
 
+// SaveBlockedMessage records a message blocked by a sender rule.
+func (s *Service) SaveBlockedMessage(ctx context.Context, alias model.Alias, ruleID string) error {
+	message := model.Message{
+		AliasID: alias.ID,
+		UserID:  alias.UserID,
+		Type:    model.Block,
+		RuleID:  ruleID,
+	}
+
+	err := s.Store.PostMessage(ctx, message)
+	if err != nil {
+		return ErrPostMessage
+	}
+
+	return nil
+}
+
+// SaveFilteredMessage records a message dropped by a filter.
+func (s *Service) SaveFilteredMessage(ctx context.Context, alias model.Alias, filterID string) error {
+	message := model.Message{
+		AliasID:  alias.ID,
+		UserID:   alias.UserID,
+		Type:     model.Block,
+		FilterID: filterID,
+	}
+
+	err := s.Store.PostMessage(ctx, message)
+	if err != nil {
+		return ErrPostMessage
+	}
+
+	return nil
+}
+
 func (s *Service) DeleteMessageByUserID(ctx context.Context, userID string) error {
 	err := s.Store.DeleteMessageByUserID(ctx, userID)
 	if err != nil {
@@ -71,15 +108,17 @@ func (s *Service) DeleteMessageByUserID(ctx context.Context, userID string) erro
 	return nil
 }
 
-func (s *Service) ValidateSendReplyDailyCount(ctx context.Context, userID string) error {
+// ValidateSendReplyDailyCount checks that n more replies or sends, one per
+// recipient, fit in the daily limit of the user.
+func (s *Service) ValidateSendReplyDailyCount(ctx context.Context, userID string, n int) error {
 	count, err := s.Store.SendReplyDailyCount(ctx, userID)
 	if err != nil {
 		log.Printf("error getting send/reply daily count: %s", err.Error())
 		return ErrGetMessagesByUser
 	}
 
-	if count >= s.Cfg.Service.MaxDailySendReply {
-		return errors.New("daily limit reached")
+	if count+n > s.Cfg.Service.MaxDailySendReply {
+		return ErrDailySendReplyLimit
 	}
 
 	return nil
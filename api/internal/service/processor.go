@@ -3,10 +3,11 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"time"
 
 	"golang.org/x/sync/errgroup"
-	"ivpn.net/email/api/internal/client/mailer"
 	"ivpn.net/email/api/internal/model"
 	"ivpn.net/email/api/internal/utils"
 )
@@ -18,15 +19,16 @@ var (
 	ErrInactiveRecipient    = errors.New("The recipient is inactive.")
 )
 
-func (s *Service) ProcessMessage(data []byte) error {
+func (s *Service) ProcessMessage(data []byte, env model.Envelope) error {
 	msg, parseErr := model.ParseMsg(data)
+	rcpts := env.Recipients(msg.To)
 	if parseErr != nil {
 		if errors.Is(parseErr, model.ErrExtractOriginalFrom) {
 			// Fail silently so bounce messages are not kept in postfix queue
 			return nil
 		}
 
-		for _, to := range msg.To {
+		for _, to := range rcpts {
 			_, alias, _, err := s.FindRecipients(msg.From, to, msg.Type)
 			if alias.UserID == "" {
 				continue
@@ -55,6 +57,13 @@ func (s *Service) ProcessMessage(data []byte) error {
 
 	// Bounce
 	if msg.Type == model.FailBounce {
+		err := s.verifyBounce(rcpts)
+		if err != nil {
+			log.Println("error processing bounce:", err)
+			// Fail silently so bounce messages are not kept in postfix queue
+			return nil
+		}
+
 		alias, err := s.FindAlias(msg.From)
 		if err != nil {
 			log.Println("error processing bounce:", err, alias.Name)
@@ -67,6 +76,11 @@ func (s *Service) ProcessMessage(data []byte) error {
 			log.Println("error processing bounce:", err, alias.Name)
 		}
 
+		err = s.SaveMessage(context.Background(), alias, model.FailBounce)
+		if err != nil {
+			log.Println("error saving bounce message:", err, alias.Name)
+		}
+
 		// Fail silently so bounce messages are not kept in postfix queue
 		return nil
 	}
@@ -77,13 +91,20 @@ func (s *Service) ProcessMessage(data []byte) error {
 		log.Println("email authentication failed:", err)
 	}
 	if !pass {
+		s.quarantineUnauthenticated(data, msg, rcpts)
+
 		// Fail silently so unauthenticated emails are not kept in postfix queue
 		return nil
 	}
 
 	var g errgroup.Group
 
-	for _, to := range msg.To {
+	// Replies and sends are queued once all recipients are known, so the
+	// daily limit counts each of them
+	sends := []queuedSend{}
+
+	// Route on envelope recipients, falling back to the To header
+	for _, to := range rcpts {
 		recipients, alias, relayType, err := s.FindRecipients(msg.From, to, msg.Type)
 		if err != nil {
 			log.Println("error processing message:", err, alias.Name)
@@ -106,6 +127,8 @@ func (s *Service) ProcessMessage(data []byte) error {
 
 			// Handle ErrDisabledAlias
 			if errors.Is(err, ErrDisabledAlias) {
+				s.quarantine(data, msg, alias, model.QuarantineDisabledAlias)
+
 				settings, err := s.GetSettings(context.Background(), alias.UserID)
 				if err != nil {
 					log.Println("error getting settings", err)
@@ -120,6 +143,40 @@ func (s *Service) ProcessMessage(data []byte) error {
 				}
 			}
 
+			// Handle ErrExpiredAlias
+			if errors.Is(err, ErrExpiredAlias) {
+				s.expireAlias(alias)
+
+				settings, err := s.GetSettings(context.Background(), alias.UserID)
+				if err != nil {
+					log.Println("error getting settings", err)
+					continue
+				}
+
+				if settings.LogIssues {
+					err := s.ProcessDiagnosticLog(alias, msg.From, to, ErrExpiredAlias.Error(), model.DisabledAlias)
+					if err != nil {
+						log.Println("error processing diagnostic log", err)
+					}
+				}
+			}
+
+			// Handle ErrScheduledAlias
+			if errors.Is(err, ErrScheduledAlias) {
+				settings, err := s.GetSettings(context.Background(), alias.UserID)
+				if err != nil {
+					log.Println("error getting settings", err)
+					continue
+				}
+
+				if settings.LogIssues {
+					err := s.ProcessDiagnosticLog(alias, msg.From, to, ErrScheduledAlias.Error(), model.DisabledAlias)
+					if err != nil {
+						log.Println("error processing diagnostic log", err)
+					}
+				}
+			}
+
 			// Handle ErrDisabledDomain
 			if errors.Is(err, ErrDisabledDomain) {
 				settings, err := s.GetSettings(context.Background(), alias.UserID)
@@ -136,6 +193,36 @@ func (s *Service) ProcessMessage(data []byte) error {
 				}
 			}
 
+			// Handle ErrBlockedSender
+			if errors.Is(err, ErrBlockedSender) {
+				var blocked blockedSenderError
+				if errors.As(err, &blocked) {
+					if err := s.SaveBlockedMessage(context.Background(), alias, blocked.ruleID); err != nil {
+						log.Println("error saving message", err)
+					}
+				}
+				if errors.Is(err, blockedContactError{}) {
+					if err := s.SaveMessage(context.Background(), alias, model.Block); err != nil {
+						log.Println("error saving message", err)
+					}
+				}
+
+				s.quarantine(data, msg, alias, model.QuarantineBlockedSender)
+
+				settings, err := s.GetSettings(context.Background(), alias.UserID)
+				if err != nil {
+					log.Println("error getting settings", err)
+					continue
+				}
+
+				if settings.LogIssues {
+					err := s.ProcessDiagnosticLog(alias, msg.From, to, ErrBlockedSender.Error(), model.BlockedSender)
+					if err != nil {
+						log.Println("error processing diagnostic log", err)
+					}
+				}
+			}
+
 			continue
 		}
 
@@ -169,74 +256,247 @@ func (s *Service) ProcessMessage(data []byte) error {
 			continue
 		}
 
-		for _, recipient := range recipients {
-			g.Go(func() error {
-				err = s.QueueMessage(msg.From, msg.FromName, recipient, data, alias, relayType, settings)
-				if err != nil {
-					return err
-				}
+		// Forward: spam policy and filters may drop the message or narrow the recipients
+		filtered := model.FilterResult{}
+		if relayType == model.Forward {
+			if dropped := s.dropSpam(msg, alias, settings, to); dropped {
+				continue
+			}
+
+			if dropped := s.limitInbound(msg, alias, to); dropped {
+				continue
+			}
+
+			filterMsg := msg
+			filterMsg.Envelope = model.Envelope{From: env.From, To: []string{to}}
+			recipients, filtered, err = s.applyFilters(filterMsg, alias, recipients)
+			if err != nil {
+				log.Println("error processing message:", err, alias.Name)
 
-				if err := s.SaveMessage(context.Background(), alias, relayType); err != nil {
-					log.Println("error saving message", err)
+				if settings.LogIssues {
+					err := s.ProcessDiagnosticLog(alias, msg.From, to, ErrFilteredMessage.Error(), model.FilteredMessage)
+					if err != nil {
+						log.Println("error processing diagnostic log", err)
+					}
 				}
 
-				return nil
+				continue
+			}
+		}
+
+		if relayType == model.Forward && len(recipients) > 0 {
+			s.seeContact(alias, msg.From)
+			s.countAliasMessage(alias)
+		}
+
+		if relayType != model.Forward {
+			for _, recipient := range recipients {
+				sends = append(sends, queuedSend{to: to, rcp: recipient, alias: alias, msgType: relayType, settings: settings})
+			}
+			continue
+		}
+
+		for _, recipient := range recipients {
+			g.Go(func() error {
+				return s.QueueMessage(msg.From, msg.FromName, recipient, data, alias, relayType, settings, filtered)
 			})
 		}
 	}
 
+	for _, send := range s.limitSends(msg, sends) {
+		g.Go(func() error {
+			return s.QueueMessage(msg.From, msg.FromName, send.rcp, data, send.alias, send.msgType, send.settings, model.FilterResult{})
+		})
+	}
+
 	// Wait for all goroutines and return first error (if any)
 	return g.Wait()
 }
 
-func (s *Service) QueueMessage(from string, fromName string, rcp model.Recipient, data []byte, alias model.Alias, msgType model.MessageType, settings model.Settings) error {
-	mailer := mailer.New(s.Cfg.SMTPClient)
+// queuedSend is a reply or send copy to one external recipient.
+type queuedSend struct {
+	to       string
+	rcp      model.Recipient
+	alias    model.Alias
+	msgType  model.MessageType
+	settings model.Settings
+}
 
-	// Queue Forward
-	if msgType == model.Forward {
-		templateData := map[string]any{
-			"alias": alias.Name,
-			"from":  from,
+// limitSends drops the replies and sends of users the message would take over
+// their daily limit. A message to several recipients is sent to all or none.
+func (s *Service) limitSends(msg model.Msg, sends []queuedSend) []queuedSend {
+	counts := map[string]int{}
+	for _, send := range sends {
+		counts[send.alias.UserID]++
+	}
+
+	allowed := map[string]bool{}
+	for userID, n := range counts {
+		err := s.ValidateSendReplyDailyCount(context.Background(), userID, n)
+		if err != nil {
+			log.Println("error validating send/reply daily count", err)
+			continue
 		}
-		generatedFrom := model.GenerateReplyTo(alias.Name, from)
-		err := mailer.Forward(generatedFrom, fromName, rcp, data, "header.tmpl", templateData, settings, alias)
+		allowed[userID] = true
+	}
+
+	queued := []queuedSend{}
+	logged := map[string]bool{}
+	for _, send := range sends {
+		if allowed[send.alias.UserID] {
+			queued = append(queued, send)
+			continue
+		}
+
+		if !send.settings.LogIssues || logged[send.alias.UserID] {
+			continue
+		}
+		logged[send.alias.UserID] = true
+
+		message := fmt.Sprintf("%s The message to %d recipients was not sent.", ErrDailySendReplyLimit.Error(), counts[send.alias.UserID])
+		err := s.ProcessDiagnosticLog(send.alias, msg.From, send.to, message, model.SendReplyLimit)
 		if err != nil {
-			if settings.LogIssues {
-				err := s.ProcessDiagnosticLog(alias, from, rcp.Email, err.Error(), model.DeferredDelivery)
-				if err != nil {
-					log.Println("error processing diagnostic log", err)
-				}
-			}
+			log.Println("error processing diagnostic log", err)
+		}
+	}
 
-			log.Println("error forwarding message [alias:", alias.Name, "]:", err)
-			return err
+	return queued
+}
+
+// QueueMessage queues a copy of the message for a single recipient. Delivery
+// happens asynchronously in the delivery workers (see DeliverMessage), which
+// apply the filter result evaluated on receipt.
+func (s *Service) QueueMessage(from string, fromName string, rcp model.Recipient, data []byte, alias model.Alias, msgType model.MessageType, settings model.Settings, filtered model.FilterResult) error {
+	// Forward: skip copies already delivered to this recipient
+	dedupeKey := ""
+	if msgType == model.Forward {
+		dedupeKey = model.DedupeKey(model.ParseMessageID(data), data, alias.ID, rcp.Email)
+		ok, err := s.Cache.SetNX(context.Background(), dedupeKey, 1, s.Cfg.Service.DedupeTTL)
+		if err != nil {
+			// Fail open, a duplicate is better than a lost message
+			log.Println("error checking duplicate message", err)
+			dedupeKey = ""
+		} else if !ok {
+			log.Println("duplicate message skipped [alias:", alias.Name, "]")
+			if err := s.SaveMessage(context.Background(), alias, model.Duplicate); err != nil {
+				log.Println("error saving message", err)
+			}
+			return nil
 		}
-	} else {
-		// Queue Reply | Send
-		err := s.ValidateSendReplyDailyCount(context.Background(), alias.UserID)
+	}
+
+	// Reply | Send
+	if msgType != model.Forward {
+		err := s.ValidateSendReplyDailyCount(context.Background(), alias.UserID, 1)
 		if err != nil {
 			log.Println("error validating send/reply daily count", err)
 			return err
 		}
+	}
+
+	delivery := model.Delivery{
+		IdempotencyKey: model.DeliveryKey(data, alias.ID, rcp.Email, msgType),
+		UserID:         alias.UserID,
+		AliasID:        alias.ID,
+		From:           from,
+		FromName:       fromName,
+		Recipient:      rcp.Email,
+		Type:           msgType,
+		Data:           data,
+		Filter:         filtered,
+	}
+
+	// Forwards are held until the next window of the alias schedule
+	if msgType == model.Forward && alias.Schedule != nil {
+		if next, ok := alias.Schedule.NextActive(time.Now()); ok && next.After(time.Now()) {
+			delivery.NextAttemptAt = next
+		}
+	}
+
+	err := s.EnqueueDelivery(context.Background(), delivery)
+	if err != nil {
+		log.Println("error queueing message [alias:", alias.Name, "]:", err)
+		s.releaseDedupeKey(dedupeKey)
+		return err
+	}
+
+	return nil
+}
 
-		name := alias.FromName
-		if name == "" {
-			name = settings.FromName
+// dropSpam drops a message flagged as spam by rspamd when the spam policy is
+// drop. Dropped messages are counted and always logged.
+func (s *Service) dropSpam(msg model.Msg, alias model.Alias, settings model.Settings, to string) bool {
+	score := model.ParseSpamScore(msg.Headers)
+	if !score.Spam || model.GetSpamPolicy(alias, settings) != model.SpamDrop {
+		return false
+	}
+
+	log.Println("spam message dropped [alias:", alias.Name, "score:", score.Score, "]")
+
+	if err := s.SaveMessage(context.Background(), alias, model.Spam); err != nil {
+		log.Println("error saving message", err)
+	}
+
+	message := fmt.Sprintf("Message dropped as spam (score %.2f / %.2f).", score.Score, score.Threshold)
+	if err := s.ProcessDiagnosticLog(alias, msg.From, to, message, model.SpamMessage); err != nil {
+		log.Println("error processing diagnostic log", err)
+	}
+
+	return true
+}
+
+// verifyBounce rejects bounces to SRS addresses we did not issue or that
+// expired, so forged bounces (backscatter) are not attributed to aliases.
+func (s *Service) verifyBounce(rcpts []string) error {
+	srs := utils.SRS{Secret: s.Cfg.SMTPClient.SRSSecret, Domain: s.Cfg.SMTPClient.SRSDomain}
+	if srs.Secret == "" || srs.Domain == "" {
+		return nil
+	}
+
+	for _, rcpt := range rcpts {
+		if !utils.IsSRS(rcpt) {
+			continue
 		}
 
-		err = mailer.Reply(alias.Name, name, rcp, data, alias)
+		_, err := srs.Reverse(rcpt, time.Now())
 		if err != nil {
-			if settings.LogIssues {
-				err := s.ProcessDiagnosticLog(alias, from, rcp.Email, err.Error(), model.DeferredDelivery)
-				if err != nil {
-					log.Println("error processing diagnostic log", err)
-				}
-			}
+			return fmt.Errorf("%w: %s", err, rcpt)
+		}
+	}
 
-			log.Println("error sending message [alias:", alias.Name, "]:", err)
+	return nil
+}
+
+// releaseDedupeKey allows a copy that was not delivered to be queued again.
+func (s *Service) releaseDedupeKey(key string) {
+	if key == "" {
+		return
+	}
+
+	if err := s.Cache.Del(context.Background(), key); err != nil {
+		log.Println("error releasing duplicate message key", err)
+	}
+}
+
+func (s *Service) sendMessage(from string, fromName string, rcp model.Recipient, data []byte, alias model.Alias, msgType model.MessageType, settings model.Settings, filtered model.FilterResult) error {
+	// Forward
+	if msgType == model.Forward {
+		templateData := map[string]any{
+			"alias": alias.Name,
+			"from":  from,
+		}
+		generatedFrom, err := s.ReplyAddress(alias, from)
+		if err != nil {
 			return err
 		}
+		return s.Mailer.Forward(generatedFrom, fromName, rcp, data, "header.tmpl", templateData, settings, alias, filtered)
 	}
 
-	return nil
+	// Reply | Send
+	name := alias.FromName
+	if name == "" {
+		name = settings.FromName
+	}
+
+	return s.Mailer.Reply(alias.Name, name, rcp, data, alias)
 }
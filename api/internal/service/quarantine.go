@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
+)
+
+var (
+	ErrGetQuarantine               = errors.New("Unable to retrieve quarantined messages.")
+	ErrGetQuarantineMessage        = errors.New("Unable to retrieve quarantined message.")
+	ErrReleaseQuarantine           = errors.New("Unable to release quarantined message. Please try again.")
+	ErrDeleteQuarantine            = errors.New("Unable to delete quarantined message. Please try again.")
+	ErrDeleteQuarantineByUserID    = errors.New("Unable to delete quarantined messages for this user.")
+	ErrQuarantineAliasNotAvailable = errors.New("The alias of this message no longer exists.")
+)
+
+type QuarantineStore interface {
+	GetQuarantine(context.Context, string) ([]model.Quarantine, error)
+	GetQuarantineMessage(context.Context, string, string) (model.Quarantine, error)
+	GetQuarantineUsage(context.Context, string) (model.QuarantineUsage, error)
+	PostQuarantine(context.Context, model.Quarantine) (model.Quarantine, error)
+	DeleteQuarantine(context.Context, string, string) error
+	DeleteQuarantineByUserID(context.Context, string) error
+	SaveQuarantineFile(context.Context, string, []byte) error
+	GetQuarantineFile(context.Context, string) ([]byte, error)
+	DeleteQuarantineFile(context.Context, string) error
+}
+
+func (s *Service) GetQuarantine(ctx context.Context, userID string) ([]model.Quarantine, error) {
+	messages, err := s.Store.GetQuarantine(ctx, userID)
+	if err != nil {
+		log.Printf("error getting quarantine: %s", err.Error())
+		return nil, ErrGetQuarantine
+	}
+
+	return messages, nil
+}
+
+// GetQuarantinePreview returns a quarantined message with its header fields.
+// The body is not returned, so it cannot load remote content.
+func (s *Service) GetQuarantinePreview(ctx context.Context, ID string, userID string) (model.QuarantinePreview, error) {
+	message, data, err := s.getQuarantineData(ctx, ID, userID)
+	if err != nil {
+		return model.QuarantinePreview{}, err
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("error parsing quarantined message: %s", err.Error())
+		return model.QuarantinePreview{}, ErrGetQuarantineMessage
+	}
+
+	return model.QuarantinePreview{Quarantine: message, Headers: msg.Header}, nil
+}
+
+// ReleaseQuarantine forwards a quarantined message to the recipients of its
+// alias, whether the alias is enabled or not, and deletes it.
+func (s *Service) ReleaseQuarantine(ctx context.Context, ID string, userID string) error {
+	message, data, err := s.getQuarantineData(ctx, ID, userID)
+	if err != nil {
+		return err
+	}
+
+	alias, err := s.Store.GetAlias(ctx, message.AliasID, userID)
+	if err != nil {
+		log.Printf("error getting alias of quarantined message: %s", err.Error())
+		return ErrQuarantineAliasNotAvailable
+	}
+
+	rcps, err := s.resolveForward(alias)
+	if err != nil {
+		return err
+	}
+
+	settings, err := s.GetSettings(ctx, userID)
+	if err != nil {
+		log.Printf("error getting settings: %s", err.Error())
+		return ErrReleaseQuarantine
+	}
+
+	// Filters still tag a released message, a drop action is overridden
+	filtered := model.FilterResult{}
+	if msg, err := model.ParseMsg(data); err == nil {
+		msg.Envelope = model.Envelope{To: []string{alias.Name}}
+		filtered = model.EvalFilters(s.getFilters(alias), alias.ID, msg)
+	}
+
+	for _, rcp := range rcps {
+		err = s.QueueMessage(message.From, message.FromName, rcp, data, alias, model.Forward, settings, filtered)
+		if err != nil {
+			log.Printf("error releasing quarantined message: %s", err.Error())
+			return ErrReleaseQuarantine
+		}
+	}
+
+	return s.DeleteQuarantine(ctx, ID, userID)
+}
+
+func (s *Service) DeleteQuarantine(ctx context.Context, ID string, userID string) error {
+	err := s.Store.DeleteQuarantine(ctx, ID, userID)
+	if err != nil {
+		log.Printf("error deleting quarantined message: %s", err.Error())
+		return ErrDeleteQuarantine
+	}
+
+	err = s.Store.DeleteQuarantineFile(ctx, ID)
+	if err != nil {
+		log.Printf("error deleting quarantine file: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteQuarantineByUserID(ctx context.Context, userID string) error {
+	messages, err := s.Store.GetQuarantine(ctx, userID)
+	if err != nil {
+		log.Printf("error getting quarantine: %s", err.Error())
+		return ErrDeleteQuarantineByUserID
+	}
+
+	for _, message := range messages {
+		err := s.Store.DeleteQuarantineFile(ctx, message.ID)
+		if err != nil {
+			log.Printf("error deleting quarantine file: %s", err.Error())
+		}
+	}
+
+	err = s.Store.DeleteQuarantineByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting quarantine by user ID: %s", err.Error())
+		return ErrDeleteQuarantineByUserID
+	}
+
+	return nil
+}
+
+// quarantine keeps a blocked message for the user when quarantine is enabled
+// (QUARANTINE_SECRET and the user setting), for QUARANTINE_DAYS.
+func (s *Service) quarantine(data []byte, msg model.Msg, alias model.Alias, reason model.QuarantineReason) {
+	if s.Cfg.Service.QuarantineSecret == "" || alias.ID == "" {
+		return
+	}
+
+	settings, err := s.GetSettings(context.Background(), alias.UserID)
+	if err != nil {
+		log.Println("error getting settings", err)
+		return
+	}
+
+	if !settings.Quarantine {
+		return
+	}
+
+	if !s.quarantineAllowed(alias, len(data)) {
+		return
+	}
+
+	encrypted, err := utils.Encrypt(s.Cfg.Service.QuarantineSecret, data)
+	if err != nil {
+		log.Printf("error encrypting quarantined message: %s", err.Error())
+		return
+	}
+
+	message, err := s.Store.PostQuarantine(context.Background(), model.Quarantine{
+		UserID:    alias.UserID,
+		AliasID:   alias.ID,
+		AliasName: alias.Name,
+		From:      msg.From,
+		FromName:  msg.FromName,
+		Subject:   msg.Subject,
+		Reason:    reason,
+		Size:      len(data),
+		ExpiresAt: time.Now().AddDate(0, 0, s.Cfg.Service.QuarantineDays),
+	})
+	if err != nil {
+		log.Printf("error saving quarantined message: %s", err.Error())
+		return
+	}
+
+	err = s.Store.SaveQuarantineFile(context.Background(), message.ID, []byte(encrypted))
+	if err != nil {
+		log.Printf("error saving quarantine file: %s", err.Error())
+		if err := s.Store.DeleteQuarantine(context.Background(), message.ID, alias.UserID); err != nil {
+			log.Printf("error deleting quarantined message: %s", err.Error())
+		}
+		return
+	}
+
+	log.Println("message quarantined [alias:", alias.Name, "reason:", reason, "]")
+}
+
+// quarantineAllowed reports whether the message fits in the quarantine quota of
+// the user (QUARANTINE_MAX_COUNT, QUARANTINE_MAX_SIZE_MB). Messages over the
+// quota are not kept.
+func (s *Service) quarantineAllowed(alias model.Alias, size int) bool {
+	usage, err := s.Store.GetQuarantineUsage(context.Background(), alias.UserID)
+	if err != nil {
+		log.Printf("error getting quarantine usage: %s", err.Error())
+		return false
+	}
+
+	maxCount := s.Cfg.Service.QuarantineMaxCount
+	maxSize := s.Cfg.Service.QuarantineMaxSize
+	if (maxCount > 0 && usage.Count >= maxCount) || (maxSize > 0 && usage.Size+size > maxSize) {
+		log.Println("quarantine quota exceeded [alias:", alias.Name, "]")
+		return false
+	}
+
+	return true
+}
+
+// quarantineUnauthenticated quarantines a message that failed authentication
+// for each alias it was sent to.
+func (s *Service) quarantineUnauthenticated(data []byte, msg model.Msg, rcpts []string) {
+	for _, to := range rcpts {
+		alias, err := s.FindAlias(to)
+		if err != nil {
+			continue
+		}
+
+		s.quarantine(data, msg, alias, model.QuarantineFailedAuth)
+	}
+}
+
+func (s *Service) getQuarantineData(ctx context.Context, ID string, userID string) (model.Quarantine, []byte, error) {
+	message, err := s.Store.GetQuarantineMessage(ctx, ID, userID)
+	if err != nil {
+		log.Printf("error getting quarantined message: %s", err.Error())
+		return model.Quarantine{}, nil, ErrGetQuarantineMessage
+	}
+
+	encrypted, err := s.Store.GetQuarantineFile(ctx, message.ID)
+	if err != nil {
+		log.Printf("error getting quarantine file: %s", err.Error())
+		return model.Quarantine{}, nil, ErrGetQuarantineMessage
+	}
+
+	data, err := utils.Decrypt(s.Cfg.Service.QuarantineSecret, string(encrypted))
+	if err != nil {
+		log.Printf("error decrypting quarantined message: %s", err.Error())
+		return model.Quarantine{}, nil, ErrGetQuarantineMessage
+	}
+
+	return message, data, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+	"ivpn.net/email/api/internal/utils"
+)
+
+// limitInbound counts a forwarded message against the inbound rate limits of
+// the alias and of its owner, and reports whether the message is dropped. An
+// alias over its limit is throttled or paused (INBOUND_LIMIT_ACTION), an
+// account over its limit is throttled.
+func (s *Service) limitInbound(msg model.Msg, alias model.Alias, to string) bool {
+	now := time.Now()
+	if alias.Throttled(now) || s.userThrottled(alias.UserID) {
+		s.dropInbound(alias)
+		return true
+	}
+
+	limit := s.Cfg.Service.InboundAliasLimit
+	if count := s.countInbound("inbound_alias_"+alias.ID, limit, now); count > limit {
+		s.limitAlias(msg, alias, to, now)
+		s.dropInbound(alias)
+		return true
+	}
+
+	limit = s.Cfg.Service.InboundUserLimit
+	if count := s.countInbound("inbound_user_"+alias.UserID, limit, now); count > limit {
+		s.limitUser(msg, alias, to, now)
+		s.dropInbound(alias)
+		return true
+	}
+
+	return false
+}
+
+// countInbound increments the counter of the current window and returns it.
+// It returns 0 when the limit is disabled, errors are logged and do not limit
+// the message either.
+func (s *Service) countInbound(prefix string, limit int, now time.Time) int {
+	window := s.Cfg.Service.InboundLimitWindow
+	if limit <= 0 || window <= 0 {
+		return 0
+	}
+
+	count, err := s.Cache.Incr(context.Background(), s.inboundKey(prefix, now), window)
+	if err != nil {
+		log.Printf("error counting inbound messages: %s", err.Error())
+		return 0
+	}
+
+	return int(count)
+}
+
+// inboundKey returns the cache key of the current rate limit window.
+func (s *Service) inboundKey(prefix string, now time.Time) string {
+	return fmt.Sprintf("%s_%d", prefix, now.UnixNano()/int64(s.Cfg.Service.InboundLimitWindow))
+}
+
+func (s *Service) limitAlias(msg model.Msg, alias model.Alias, to string, now time.Time) {
+	key := s.inboundKey("inbound_alias_limited_"+alias.ID, now)
+	ok, err := s.Cache.SetNX(context.Background(), key, 1, s.Cfg.Service.InboundLimitWindow)
+	if err != nil {
+		log.Printf("error limiting alias: %s", err.Error())
+		return
+	}
+
+	// Already limited by a concurrent message in this window
+	if !ok {
+		return
+	}
+
+	var action string
+	if s.Cfg.Service.InboundLimitAction == "pause" {
+		err = s.Store.PauseAlias(context.Background(), alias.ID, now)
+		action = "Forwarding is paused until the alias is enabled again."
+	} else {
+		until := now.Add(s.Cfg.Service.InboundThrottle)
+		err = s.Store.ThrottleAlias(context.Background(), alias.ID, until)
+		action = fmt.Sprintf("Forwarding is throttled until %s.", until.UTC().Format(time.RFC1123))
+	}
+	if err != nil {
+		log.Printf("error limiting alias: %s", err.Error())
+		return
+	}
+
+	log.Println("inbound rate limit exceeded [alias:", alias.Name, "]")
+
+	message := fmt.Sprintf("Alias received more than %d messages in %s. %s", s.Cfg.Service.InboundAliasLimit, s.Cfg.Service.InboundLimitWindow, action)
+	if err := s.ProcessDiagnosticLog(alias, msg.From, to, message, model.InboundRateLimit); err != nil {
+		log.Println("error processing diagnostic log", err)
+	}
+
+	s.notifyInboundLimit(alias.UserID, "Your alias "+alias.Name, s.Cfg.Service.InboundAliasLimit, action)
+}
+
+func (s *Service) limitUser(msg model.Msg, alias model.Alias, to string, now time.Time) {
+	until := now.Add(s.Cfg.Service.InboundThrottle)
+	ok, err := s.Cache.SetNX(context.Background(), "inbound_throttle_"+alias.UserID, until.Unix(), s.Cfg.Service.InboundThrottle)
+	if err != nil {
+		log.Printf("error limiting user: %s", err.Error())
+		return
+	}
+
+	// Already throttled by a concurrent message
+	if !ok {
+		return
+	}
+
+	log.Println("inbound rate limit exceeded [user:", alias.UserID, "]")
+
+	action := fmt.Sprintf("Forwarding to all your aliases is throttled until %s.", until.UTC().Format(time.RFC1123))
+	message := fmt.Sprintf("Account received more than %d messages in %s. %s", s.Cfg.Service.InboundUserLimit, s.Cfg.Service.InboundLimitWindow, action)
+	if err := s.ProcessDiagnosticLog(alias, msg.From, to, message, model.InboundRateLimit); err != nil {
+		log.Println("error processing diagnostic log", err)
+	}
+
+	s.notifyInboundLimit(alias.UserID, "Your account", s.Cfg.Service.InboundUserLimit, action)
+}
+
+func (s *Service) userThrottled(userID string) bool {
+	if s.Cfg.Service.InboundUserLimit <= 0 {
+		return false
+	}
+
+	_, err := s.Cache.Get(context.Background(), "inbound_throttle_"+userID)
+	return err == nil
+}
+
+func (s *Service) dropInbound(alias model.Alias) {
+	if err := s.SaveMessage(context.Background(), alias, model.Block); err != nil {
+		log.Println("error saving message", err)
+	}
+}
+
+func (s *Service) notifyInboundLimit(userID string, target string, limit int, action string) {
+	user, err := s.Store.GetUser(context.Background(), userID)
+	if err != nil {
+		log.Printf("error getting user: %s", err.Error())
+		return
+	}
+
+	utils.Background(func() {
+		data := map[string]any{
+			"target": target,
+			"limit":  limit,
+			"window": s.Cfg.Service.InboundLimitWindow.String(),
+			"action": action,
+			"from":   s.Cfg.SMTPClient.SenderName,
+		}
+		err := s.Mailer.SendTemplate(user.Email, "["+s.Cfg.SMTPClient.SenderName+"] Inbound Rate Limit Exceeded", "inbound_limit.tmpl", data)
+		if err != nil {
+			log.Printf("error sending inbound rate limit email: %s", err.Error())
+		}
+	})
+}
@@ -5,9 +5,9 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
-	"ivpn.net/email/api/internal/client/mailer"
 	"ivpn.net/email/api/internal/model"
 	"ivpn.net/email/api/internal/utils"
 )
@@ -129,8 +129,7 @@ func (s *Service) PostRecipient(ctx context.Context, recipient model.Recipient)
 			"otp":  otp.Secret,
 			"from": s.Cfg.SMTPClient.SenderName,
 		}
-		mailer := mailer.New(s.Cfg.SMTPClient)
-		err = mailer.SendTemplate(recipient.Email, "Verify Your Recipient Email Address", "otp_recipient.tmpl", data)
+		err = s.Mailer.SendTemplate(recipient.Email, "Verify Your Recipient Email Address", "otp_recipient.tmpl", data)
 		if err != nil {
 			log.Printf("error creating recipient: %s", err.Error())
 		}
@@ -163,8 +162,7 @@ func (s *Service) SendRecipientOTP(ctx context.Context, ID string, userID string
 			"otp":  otp.Secret,
 			"from": s.Cfg.SMTPClient.SenderName,
 		}
-		mailer := mailer.New(s.Cfg.SMTPClient)
-		err = mailer.SendTemplate(recipient.Email, "Verify Your Recipient Email Address", "otp_recipient.tmpl", data)
+		err = s.Mailer.SendTemplate(recipient.Email, "Verify Your Recipient Email Address", "otp_recipient.tmpl", data)
 		if err != nil {
 			log.Printf("error sending OTP: %s", err.Error())
 		}
@@ -292,7 +290,7 @@ func (s *Service) DeleteRecipientByUserID(ctx context.Context, userID string) er
 }
 
 func (s *Service) FindRecipients(from string, to string, msgType model.MessageType) ([]model.Recipient, model.Alias, model.MessageType, error) {
-	aliasName, replyTo := model.ParseReplyTo(to)
+	aliasName, replyTo := s.parseReplyAddress(to)
 
 	alias, err := s.GetAliasByName(aliasName)
 	if err != nil {
@@ -311,12 +309,24 @@ func (s *Service) FindRecipients(from string, to string, msgType model.MessageTy
 					return rcps, catchAllAlias, msgType, nil
 				}
 
+				if err := s.checkSenderRules(from, catchAllAlias); err != nil {
+					return []model.Recipient{}, catchAllAlias, 0, err
+				}
+
+				if err := s.checkContact(from, catchAllAlias); err != nil {
+					return []model.Recipient{}, catchAllAlias, 0, err
+				}
+
 				return rcps, catchAllAlias, model.Forward, nil
 			}
 		}
 		return []model.Recipient{}, model.Alias{Name: aliasName}, 0, err
 	}
 
+	if err = s.checkAliasExpired(alias); err != nil {
+		return []model.Recipient{}, alias, 0, err
+	}
+
 	if err = s.checkAliasEnabled(alias); err != nil {
 		return []model.Recipient{}, alias, 0, err
 	}
@@ -333,6 +343,18 @@ func (s *Service) FindRecipients(from string, to string, msgType model.MessageTy
 		return rcps, alias, msgType, nil
 	}
 
+	if err = s.checkAliasSchedule(alias); err != nil {
+		return []model.Recipient{}, alias, 0, err
+	}
+
+	if err = s.checkSenderRules(from, alias); err != nil {
+		return []model.Recipient{}, alias, 0, err
+	}
+
+	if err = s.checkContact(from, alias); err != nil {
+		return []model.Recipient{}, alias, 0, err
+	}
+
 	rcps, err := s.resolveForward(alias)
 	if err != nil {
 		return []model.Recipient{}, alias, 0, err
@@ -341,16 +363,77 @@ func (s *Service) FindRecipients(from string, to string, msgType model.MessageTy
 	return rcps, alias, model.Forward, nil
 }
 
-func (s *Service) checkAliasEnabled(alias model.Alias) error {
-	if alias.Enabled {
+// checkAliasExpired blocks mail to an alias past its expiration date or
+// message limit.
+func (s *Service) checkAliasExpired(alias model.Alias) error {
+	if !alias.Expired(time.Now()) {
 		return nil
 	}
 
+	return ErrExpiredAlias
+}
+
+// expireAlias disables or deletes an expired alias (ALIAS_EXPIRED_ACTION) and
+// records the blocked message.
+func (s *Service) expireAlias(alias model.Alias) {
+	if s.Cfg.Service.AliasExpiredAction == "delete" {
+		if err := s.DeleteAlias(context.Background(), alias.ID, alias.UserID); err != nil {
+			log.Println("error deleting expired alias", err)
+		}
+	} else if alias.Enabled {
+		if err := s.Store.DisableAlias(context.Background(), alias.ID); err != nil {
+			log.Println("error disabling expired alias", err)
+		}
+	}
+
 	if err := s.SaveMessage(context.Background(), alias, model.Block); err != nil {
 		log.Println("error saving message", err)
 	}
+}
 
-	return ErrDisabledAlias
+// countAliasMessage counts a forwarded message against the message limit of
+// the alias.
+func (s *Service) countAliasMessage(alias model.Alias) {
+	if alias.MaxMessages <= 0 {
+		return
+	}
+
+	if err := s.Store.IncrementAliasReceived(context.Background(), alias.ID); err != nil {
+		log.Println("error counting alias message", err)
+	}
+}
+
+func (s *Service) checkAliasEnabled(alias model.Alias) error {
+	if !alias.Enabled {
+		if err := s.SaveMessage(context.Background(), alias, model.Block); err != nil {
+			log.Println("error saving message", err)
+		}
+
+		return ErrDisabledAlias
+	}
+
+	return nil
+}
+
+// checkAliasSchedule blocks mail forwarded to an alias outside its schedule,
+// or lets QueueMessage hold it until the next window. Replies and sends from
+// the alias are not scheduled.
+func (s *Service) checkAliasSchedule(alias model.Alias) error {
+	if alias.Schedule != nil && !alias.Schedule.Active(time.Now()) {
+		if alias.Schedule.GetAction() == model.ScheduleHold {
+			if _, ok := alias.Schedule.NextActive(time.Now()); ok {
+				return nil
+			}
+		}
+
+		if err := s.SaveMessage(context.Background(), alias, model.Block); err != nil {
+			log.Println("error saving message", err)
+		}
+
+		return ErrScheduledAlias
+	}
+
+	return nil
 }
 
 func (s *Service) checkCustomDomain(alias model.Alias) error {
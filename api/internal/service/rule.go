@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetRules      = errors.New("Unable to retrieve sender rules.")
+	ErrGetRule       = errors.New("Unable to retrieve sender rule.")
+	ErrPostRule      = errors.New("Unable to create sender rule. Please try again.")
+	ErrUpdateRule    = errors.New("Unable to update sender rule. Please try again.")
+	ErrDeleteRule    = errors.New("Unable to delete sender rule. Please try again.")
+	ErrRulePattern   = errors.New("Please enter a valid email address, domain or wildcard pattern.")
+	ErrRuleAlias     = errors.New("Unable to find the alias for this sender rule.")
+	ErrBlockedSender = errors.New("sender blocked:")
+)
+
+type RuleStore interface {
+	GetRules(context.Context, string) ([]model.Rule, error)
+	GetRule(context.Context, string, string) (model.Rule, error)
+	GetSenderRules(context.Context, string, string) ([]model.Rule, error)
+	PostRule(context.Context, model.Rule) (model.Rule, error)
+	UpdateRule(context.Context, model.Rule) error
+	DeleteRule(context.Context, string, string) error
+	DeleteRulesByUserID(context.Context, string) error
+}
+
+func (s *Service) GetRules(ctx context.Context, userID string) ([]model.Rule, error) {
+	rules, err := s.Store.GetRules(ctx, userID)
+	if err != nil {
+		log.Printf("error getting rules: %s", err.Error())
+		return nil, ErrGetRules
+	}
+
+	return rules, nil
+}
+
+func (s *Service) GetRule(ctx context.Context, ruleID string, userID string) (model.Rule, error) {
+	rule, err := s.Store.GetRule(ctx, ruleID, userID)
+	if err != nil {
+		log.Printf("error getting rule: %s", err.Error())
+		return model.Rule{}, ErrGetRule
+	}
+
+	return rule, nil
+}
+
+func (s *Service) PostRule(ctx context.Context, rule model.Rule) (model.Rule, error) {
+	if err := s.validateRule(ctx, &rule); err != nil {
+		return model.Rule{}, err
+	}
+
+	created, err := s.Store.PostRule(ctx, rule)
+	if err != nil {
+		log.Printf("error creating rule: %s", err.Error())
+		return model.Rule{}, ErrPostRule
+	}
+
+	return created, nil
+}
+
+func (s *Service) UpdateRule(ctx context.Context, rule model.Rule) error {
+	if err := s.validateRule(ctx, &rule); err != nil {
+		return err
+	}
+
+	err := s.Store.UpdateRule(ctx, rule)
+	if err != nil {
+		log.Printf("error updating rule: %s", err.Error())
+		return ErrUpdateRule
+	}
+
+	return nil
+}
+
+func (s *Service) DeleteRule(ctx context.Context, ruleID string, userID string) error {
+	err := s.Store.DeleteRule(ctx, ruleID, userID)
+	if err != nil {
+		log.Printf("error deleting rule: %s", err.Error())
+		return ErrDeleteRule
+	}
+
+	return nil
+}
+
+func (s *Service) validateRule(ctx context.Context, rule *model.Rule) error {
+	pattern, err := model.NormalizeRulePattern(rule.Pattern)
+	if err != nil {
+		return ErrRulePattern
+	}
+	rule.Pattern = pattern
+
+	if rule.AliasID != "" {
+		_, err := s.Store.GetAlias(ctx, rule.AliasID, rule.UserID)
+		if err != nil {
+			log.Printf("error getting rule alias: %s", err.Error())
+			return ErrRuleAlias
+		}
+	}
+
+	return nil
+}
+
+// blockedSenderError is returned for a sender blocked by a rule, so the
+// message can be recorded against the rule once recipients are resolved.
+type blockedSenderError struct {
+	ruleID string
+}
+
+func (e blockedSenderError) Error() string {
+	return ErrBlockedSender.Error() + " rule " + e.ruleID
+}
+
+func (e blockedSenderError) Unwrap() error {
+	return ErrBlockedSender
+}
+
+// checkSenderRules blocks the sender when it matches a block rule of the alias
+// or the account and no allow rule takes precedence. Rules are not enforced
+// if they can't be loaded, so a database error never drops mail.
+func (s *Service) checkSenderRules(from string, alias model.Alias) error {
+	rules, err := s.Store.GetSenderRules(context.Background(), alias.UserID, alias.ID)
+	if err != nil {
+		log.Printf("error getting sender rules: %s", err.Error())
+		return nil
+	}
+
+	rule, ok := model.MatchRules(rules, alias.ID, from)
+	if !ok || rule.Action != model.RuleBlock {
+		return nil
+	}
+
+	return blockedSenderError{ruleID: rule.ID}
+}
@@ -6,6 +6,7 @@ import (
 
 	"ivpn.net/email/api/config"
 	"ivpn.net/email/api/internal/client/http"
+	"ivpn.net/email/api/internal/client/mailer"
 )
 
 type Store interface {
@@ -20,29 +21,47 @@ type Store interface {
 	LogStore
 	AccessKeyStore
 	DomainStore
+	DeliveryStore
+	RuleStore
+	FilterStore
+	DomainKeyStore
+	ContactStore
+	QuarantineStore
+	AuditEventStore
 }
 
 type Cache interface {
 	Set(context.Context, string, any, time.Duration) error
 	Get(context.Context, string) (string, error)
 	Del(context.Context, string) error
-	Incr(context.Context, string, time.Duration) error
+	Incr(context.Context, string, time.Duration) (int64, error)
+	SetNX(context.Context, string, any, time.Duration) (bool, error)
 }
 
 type Service struct {
-	Cfg   config.Config
-	Store Store
-	Cache Cache
-	Http  http.Http
+	Cfg    config.Config
+	Store  Store
+	Cache  Cache
+	Http   http.Http
+	Mailer mailer.Mailer
+	wake   chan struct{}
+	keys   *domainKeyCache
 }
 
 func New(cfg config.Config, store Store, cache Cache) *Service {
-	return &Service{
+	s := &Service{
 		Cfg:   cfg,
 		Store: store,
 		Cache: cache,
 		Http: http.Http{
 			Cfg: cfg.API,
 		},
+		Mailer: mailer.New(cfg.SMTPClient),
+		wake:   make(chan struct{}, 1),
+		keys:   newDomainKeyCache(),
 	}
+	s.Mailer.SetKeyring(s)
+	s.Mailer.SetContacts(s)
+
+	return s
 }
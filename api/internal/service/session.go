@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/go-webauthn/webauthn/webauthn"
 	"ivpn.net/email/api/internal/model"
@@ -13,13 +16,29 @@ var (
 	ErrGetSession    = errors.New("Unable to retrieve session by token.")
 	ErrSaveSession   = errors.New("Unable to save session. Please try again.")
 	ErrDeleteSession = errors.New("Unable to delete session. Please try again.")
+	ErrGetSessions   = errors.New("Unable to retrieve sessions.")
+)
+
+const (
+	// sessionSeenInterval limits how often the last seen time of a session
+	// is written
+	sessionSeenInterval = 5 * time.Minute
+
+	// userAgentMaxLen limits the user agent stored with sessions and events
+	userAgentMaxLen = 255
 )
 
 type SessionStore interface {
 	GetSession(context.Context, string) (model.Session, bool, error)
 	GetSessionCount(context.Context, string) (int, error)
 	SaveSession(context.Context, webauthn.SessionData, string, string, time.Time) error
+	SaveUserSession(context.Context, webauthn.SessionData, string, string, string, string, time.Time) error
+	SaveAccessKeySession(context.Context, webauthn.SessionData, string, string, string, string, string, time.Time) error
+	GetSessions(context.Context, string) ([]model.Session, error)
+	UpdateSessionLastSeen(context.Context, string, time.Time, string) error
 	DeleteSession(context.Context, string) error
+	DeleteSessionByID(context.Context, string, string) error
+	DeleteOtherSessions(context.Context, string, string) error
 	DeleteSessionByUserID(context.Context, string) error
 }
 
@@ -63,6 +82,95 @@ func (s *Service) SaveSession(ctx context.Context, session webauthn.SessionData,
 	return nil
 }
 
+func (s *Service) SaveUserSession(ctx context.Context, session webauthn.SessionData, token string, userID string, ip string, userAgent string, exp time.Time) error {
+	err := s.Store.SaveUserSession(ctx, session, token, userID, ip, truncateUserAgent(userAgent), exp)
+	if err != nil {
+		return ErrSaveSession
+	}
+
+	return nil
+}
+
+func (s *Service) SaveAccessKeySession(ctx context.Context, session webauthn.SessionData, token string, userID string, accessKeyID string, ip string, userAgent string, exp time.Time) error {
+	err := s.Store.SaveAccessKeySession(ctx, session, token, userID, accessKeyID, ip, truncateUserAgent(userAgent), exp)
+	if err != nil {
+		return ErrSaveSession
+	}
+
+	return nil
+}
+
+// GetSessions returns the signed in sessions of a user, marking currentID.
+func (s *Service) GetSessions(ctx context.Context, userID string, currentID string) ([]model.Session, error) {
+	sessions, err := s.Store.GetSessions(ctx, userID)
+	if err != nil {
+		log.Printf("error getting sessions: %s", err.Error())
+		return nil, ErrGetSessions
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records that a signed in session was used from ip, at most
+// once per sessionSeenInterval.
+func (s *Service) TouchSession(ctx context.Context, session model.Session, ip string) {
+	if !session.SignedIn {
+		return
+	}
+
+	now := time.Now()
+	if session.LastSeenAt != nil && now.Sub(*session.LastSeenAt) < sessionSeenInterval && session.IP == ip {
+		return
+	}
+
+	err := s.Store.UpdateSessionLastSeen(ctx, session.ID, now, ip)
+	if err != nil {
+		log.Printf("error updating session last seen: %s", err.Error())
+	}
+}
+
+func (s *Service) DeleteSessionByID(ctx context.Context, ID string, userID string) error {
+	err := s.Store.DeleteSessionByID(ctx, ID, userID)
+	if err != nil {
+		log.Printf("error deleting session: %s", err.Error())
+		return ErrDeleteSession
+	}
+
+	return nil
+}
+
+// DeleteOtherSessions signs a user out everywhere except the session
+// exceptID, including access key sessions.
+func (s *Service) DeleteOtherSessions(ctx context.Context, userID string, exceptID string) error {
+	err := s.Store.DeleteOtherSessions(ctx, userID, exceptID)
+	if err != nil {
+		log.Printf("error deleting other sessions: %s", err.Error())
+		return ErrDeleteSession
+	}
+
+	return nil
+}
+
+// truncateUserAgent cuts the user agent to userAgentMaxLen bytes on a rune
+// boundary, with invalid UTF-8 removed, so it fits a utf8mb4 column.
+func truncateUserAgent(userAgent string) string {
+	userAgent = strings.ToValidUTF8(userAgent, "")
+	if len(userAgent) <= userAgentMaxLen {
+		return userAgent
+	}
+
+	i := userAgentMaxLen
+	for i > 0 && !utf8.RuneStart(userAgent[i]) {
+		i--
+	}
+
+	return userAgent[:i]
+}
+
 func (s *Service) DeleteSession(ctx context.Context, token string) error {
 	err := s.Store.DeleteSession(ctx, token)
 	if err != nil {
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetStatsSeries = errors.New("Unable to retrieve statistics.")
+	ErrStatsRange     = errors.New("Please select a valid date range and interval.")
+)
+
+// GetStatsSeries returns message counts of the user, or of a single alias
+// when aliasID is set, grouped by bucket. The range defaults to the last 30 days.
+func (s *Service) GetStatsSeries(ctx context.Context, userID string, aliasID string, from time.Time, to time.Time, bucket model.StatsBucket) (model.StatsSeries, error) {
+	if aliasID != "" {
+		_, err := s.Store.GetAlias(ctx, aliasID, userID)
+		if err != nil {
+			log.Printf("error getting stats series: %s", err.Error())
+			return model.StatsSeries{}, ErrGetAlias
+		}
+	}
+
+	if bucket == "" {
+		bucket = model.BucketDay
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = bucket.Truncate(to.AddDate(0, 0, -30))
+	}
+
+	series, err := model.NewStatsSeries(from, to, bucket)
+	if err != nil {
+		return model.StatsSeries{}, ErrStatsRange
+	}
+
+	counts, err := s.Store.GetMessageCounts(ctx, userID, aliasID, from, to)
+	if err != nil {
+		log.Printf("error getting stats series: %s", err.Error())
+		return model.StatsSeries{}, ErrGetStatsSeries
+	}
+
+	for _, count := range counts {
+		series.Add(count.Time, count.Type, count.Count)
+	}
+
+	return series, nil
+}
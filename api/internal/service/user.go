@@ -12,7 +12,6 @@ import (
 	"slices"
 
 	"github.com/go-sql-driver/mysql"
-	"ivpn.net/email/api/internal/client/mailer"
 	"ivpn.net/email/api/internal/model"
 	"ivpn.net/email/api/internal/utils"
 )
@@ -230,8 +229,7 @@ func (s *Service) SendUserOTP(ctx context.Context, userID string) error {
 			"otp":  otp.Secret,
 			"from": s.Cfg.SMTPClient.SenderName,
 		}
-		mailer := mailer.New(s.Cfg.SMTPClient)
-		err = mailer.SendTemplate(user.Email, "Verify Your Email Address", "otp_account.tmpl", data)
+		err = s.Mailer.SendTemplate(user.Email, "Verify Your Email Address", "otp_account.tmpl", data)
 		if err != nil {
 			log.Printf("error sending OTP: %s", err.Error())
 		}
@@ -375,6 +373,42 @@ func (s *Service) DeleteUser(ctx context.Context, userID string, OTP string) err
 		return ErrDeleteUser
 	}
 
+	err = s.Store.DeleteDomainKeysByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
+	err = s.Store.DeleteContactsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
+	err = s.DeleteQuarantineByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
+	err = s.Store.DeleteAuditEventsByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
+	err = s.Store.DeleteRulesByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
+	err = s.Store.DeleteFiltersByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("error deleting user: %s", err.Error())
+		return ErrDeleteUser
+	}
+
 	err = s.Store.DeleteSessionByUserID(ctx, userID)
 	if err != nil {
 		log.Printf("error deleting user: %s", err.Error())
@@ -505,8 +539,7 @@ func (s *Service) InitiatePasswordReset(ctx context.Context, email string) error
 			"origin":     s.Cfg.API.ApiAllowOrigin,
 			"expiration": s.Cfg.Service.OTPExpiration.Minutes(),
 		}
-		mailer := mailer.New(s.Cfg.SMTPClient)
-		err = mailer.SendTemplate(user.Email, "["+s.Cfg.SMTPClient.SenderName+"] Reset Password Notification", "password_reset.tmpl", data)
+		err = s.Mailer.SendTemplate(user.Email, "["+s.Cfg.SMTPClient.SenderName+"] Reset Password Notification", "password_reset.tmpl", data)
 		if err != nil {
 			log.Printf("error initiating password reset: %s", err.Error())
 		}
@@ -515,11 +548,11 @@ func (s *Service) InitiatePasswordReset(ctx context.Context, email string) error
 	return nil
 }
 
-func (s *Service) ResetPassword(ctx context.Context, otp string, password string) error {
+func (s *Service) ResetPassword(ctx context.Context, otp string, password string) (string, error) {
 	email, err := s.Cache.Get(ctx, "reset_"+otp)
 	if err != nil {
 		log.Printf("error resetting password: %s", err.Error())
-		return ErrExpiredOTP
+		return "", ErrExpiredOTP
 	}
 
 	err = s.Cache.Del(ctx, "reset_"+otp)
@@ -530,22 +563,28 @@ func (s *Service) ResetPassword(ctx context.Context, otp string, password string
 	user, err := s.Store.GetUserByEmail(ctx, email)
 	if err != nil {
 		log.Printf("error resetting password: %s", err.Error())
-		return ErrIncorrectEmail
+		return "", ErrIncorrectEmail
 	}
 
 	err = user.SetPassword(password)
 	if err != nil {
 		log.Printf("error resetting password: %s", err.Error())
-		return ErrChangePassword
+		return "", ErrChangePassword
 	}
 
 	err = s.Store.SaveUser(ctx, user)
 	if err != nil {
 		log.Printf("error resetting password: %s", err.Error())
-		return ErrChangePassword
+		return "", ErrChangePassword
 	}
 
-	return nil
+	// Sign out everywhere, whoever knew the old password
+	err = s.Store.DeleteSessionByUserID(ctx, user.ID)
+	if err != nil {
+		log.Printf("error resetting password: %s", err.Error())
+	}
+
+	return user.ID, nil
 }
 
 func (s *Service) TotpEnable(ctx context.Context, userID string) (model.TOTPNew, error) {
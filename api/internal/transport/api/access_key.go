@@ -18,12 +18,15 @@ var (
 	ErrPostAccessKey    = "Unable to create access key. Please try again."
 	ErrDeleteAccessKey  = "Unable to delete access key. Please try again."
 	ErrInvalidAccessKey = "Invalid access key provided."
+	ErrAccessKeyScopes  = "Missing or invalid access key scopes, or invalid allowed IPs."
 	ErrGetDefaults      = "Unable to retrieve default settings."
 )
 
 type AccessKeyService interface {
 	GetAccessKeys(context.Context, string) ([]model.AccessKey, error)
 	GetAccessKey(context.Context, string) (model.AccessKey, error)
+	GetSessionAccessKey(context.Context, string) (model.AccessKey, error)
+	RecordAccessKeyUsage(context.Context, model.AccessKey, string)
 	PostAccessKey(context.Context, string, model.AccessKey) (model.AccessKey, error)
 	DeleteAccessKey(context.Context, string, string) error
 	GetDefaults(context.Context, string) (model.Settings, []string, error)
@@ -81,6 +84,13 @@ func (h *Handler) PostAccessKey(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validate scopes and allowed IPs
+	if model.ValidateScopes(req.Scopes) != nil || model.ValidateAllowedIPs(req.AllowedIPs) != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrAccessKeyScopes,
+		})
+	}
+
 	// Create token
 	token, err := model.GenAccessKeyToken()
 	if err != nil {
@@ -95,6 +105,8 @@ func (h *Handler) PostAccessKey(c *fiber.Ctx) error {
 		TokenPlain: &token,
 		Name:       req.Name,
 		ExpiresAt:  model.NeverExpires(),
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
 	}
 
 	// Set expiration if provided
@@ -116,6 +128,8 @@ func (h *Handler) PostAccessKey(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, userId, model.AuditAccessKeyCreated, accessKey.Name)
+
 	// Return the token
 	return c.Status(200).JSON(fiber.Map{
 		"token": accessKey.ID + "." + token,
@@ -142,6 +156,8 @@ func (h *Handler) DeleteAccessKey(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, userId, model.AuditAccessKeyDeleted, "")
+
 	return nil
 }
 
@@ -183,6 +199,12 @@ func (h *Handler) Authenticate(c *fiber.Ctx) error {
 		})
 	}
 
+	if !accessKey.AllowsIP(c.IP()) {
+		return c.Status(403).JSON(fiber.Map{
+			"error": auth.ErrAccessKeyIP,
+		})
+	}
+
 	// Get User
 	user, err := h.Service.GetUser(c.Context(), accessKey.UserId)
 	if err != nil {
@@ -216,7 +238,7 @@ func (h *Handler) Authenticate(c *fiber.Ctx) error {
 			"error": ErrSaveSession,
 		})
 	}
-	err = h.Service.SaveSession(c.Context(), sessionData, token, user.ID, exp)
+	err = h.Service.SaveAccessKeySession(c.Context(), sessionData, token, user.ID, accessKey.ID, c.IP(), c.Get(fiber.HeaderUserAgent), exp)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": ErrSaveSession,
@@ -295,5 +317,6 @@ func (h *Handler) GetDefaults(c *fiber.Ctx) error {
 		"from_name":      settings.FromName,
 		"log_issues":     settings.LogIssues,
 		"remove_header":  settings.RemoveHeader,
+		"spam_policy":    settings.SpamPolicy,
 	})
 }
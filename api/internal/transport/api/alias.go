@@ -2,8 +2,10 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -18,6 +20,8 @@ var (
 	ErrInvalidDomain    = "Selected domain is invalid."
 	ErrUnverifiedRcp    = "The recipient address has not been verified."
 	RestoreAliasSuccess = "Alias restored successfully."
+	ErrAliasExpiresAt   = "The expiration date must be in the future."
+	ErrAliasSchedule    = "The alias schedule is invalid."
 )
 
 type AliasService interface {
@@ -227,6 +231,20 @@ func (h *Handler) PostAlias(c *fiber.Ctx) error {
 		})
 	}
 
+	// Validate expiration date
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrAliasExpiresAt,
+		})
+	}
+
+	// Validate schedule
+	if req.Schedule != nil && req.Schedule.Validate() != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrAliasSchedule,
+		})
+	}
+
 	// Validate catch-all suffix
 	if req.Format == model.AliasFormatCatchAll && req.WildcardLocalPart == "" {
 		return c.Status(400).JSON(fiber.Map{
@@ -253,6 +271,14 @@ func (h *Handler) PostAlias(c *fiber.Ctx) error {
 		Enabled:     req.Enabled,
 		Recipients:  model.GetEmails(rcps),
 		FromName:    req.FromName,
+		ExpiresAt:   req.ExpiresAt,
+		Schedule:    req.Schedule,
+	}
+	if req.MaxMessages != nil {
+		alias.MaxMessages = *req.MaxMessages
+	}
+	if req.SpamPolicy != nil {
+		alias.SpamPolicy = model.SpamPolicy(*req.SpamPolicy)
 	}
 
 	localPart := req.LocalPart
@@ -294,6 +320,34 @@ func (h *Handler) UpdateAlias(c *fiber.Ctx) error {
 		})
 	}
 
+	if req.MaxMessages != nil && *req.MaxMessages < 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	if req.Schedule != nil && req.Schedule.Validate() != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrAliasSchedule,
+		})
+	}
+
+	current, err := h.Service.GetAlias(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	nulls := nullFields(c.Body())
+
+	// Validate expiration date, an expired alias can be saved with its date unchanged
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) &&
+		(current.ExpiresAt == nil || !current.ExpiresAt.Equal(*req.ExpiresAt)) {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrAliasExpiresAt,
+		})
+	}
+
 	rcps, err := h.Service.GetVerifiedRecipients(c.Context(), req.Recipients, userID)
 	if err != nil || len(rcps) == 0 {
 		return c.Status(400).JSON(fiber.Map{
@@ -310,6 +364,26 @@ func (h *Handler) UpdateAlias(c *fiber.Ctx) error {
 	}
 	alias.ID = c.Params("id")
 
+	// Keep the stored spam policy, expiration, message limit and schedule
+	// when the request leaves them out, expires_at and schedule are cleared
+	// with null and max_messages with 0
+	alias.SpamPolicy = current.SpamPolicy
+	if req.SpamPolicy != nil {
+		alias.SpamPolicy = model.SpamPolicy(*req.SpamPolicy)
+	}
+	alias.ExpiresAt = current.ExpiresAt
+	if req.ExpiresAt != nil || nulls["expires_at"] {
+		alias.ExpiresAt = req.ExpiresAt
+	}
+	alias.MaxMessages = current.MaxMessages
+	if req.MaxMessages != nil {
+		alias.MaxMessages = *req.MaxMessages
+	}
+	alias.Schedule = current.Schedule
+	if req.Schedule != nil || nulls["schedule"] {
+		alias.Schedule = req.Schedule
+	}
+
 	err = h.Service.UpdateAlias(c.Context(), alias)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -373,3 +447,21 @@ func (h *Handler) RestoreAlias(c *fiber.Ctx) error {
 		"message": RestoreAliasSuccess,
 	})
 }
+
+// nullFields returns the top level fields of a JSON body set to null, which
+// tells a field cleared apart from one left out.
+func nullFields(body []byte) map[string]bool {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+
+	nulls := map[string]bool{}
+	for name, value := range fields {
+		if string(value) == "null" {
+			nulls[name] = true
+		}
+	}
+
+	return nulls
+}
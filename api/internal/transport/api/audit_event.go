@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+const (
+	auditDefaultLimit = 50
+	auditMaxLimit     = 100
+)
+
+type AuditEventService interface {
+	GetAuditEvents(context.Context, string, int, int) (model.AuditEventList, error)
+	PostAuditEvent(context.Context, model.AuditEvent)
+}
+
+// @Summary Get audit log
+// @Description Get security-relevant events of the authenticated user, newest first
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page"
+// @Param limit query int false "Events per page (max 100)"
+// @Success 200 {object} model.AuditEventList
+// @Failure 400 {object} ErrorRes
+// @Router /user/audit [get]
+func (h *Handler) GetAuditEvents(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = auditDefaultLimit
+	}
+	limit = min(limit, auditMaxLimit)
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 0
+	}
+
+	list, err := h.Service.GetAuditEvents(c.Context(), userID, limit, page)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(list)
+}
+
+// audit records an event in the audit log of a user with the IP and user
+// agent of the request.
+func (h *Handler) audit(c *fiber.Ctx, userID string, eventType model.AuditEventType, detail string) {
+	h.Service.PostAuditEvent(c.Context(), model.AuditEvent{
+		UserID:    userID,
+		Type:      eventType,
+		Detail:    detail,
+		IP:        c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrUpdateContact      = "Unable to update contact. Please try again."
+	BlockContactSuccess   = "Contact blocked successfully."
+	UnblockContactSuccess = "Contact unblocked successfully."
+)
+
+type ContactService interface {
+	GetContacts(context.Context, string, string) ([]model.Contact, error)
+	PostContact(context.Context, string, string, string) (model.Contact, error)
+	UpdateContactBlocked(context.Context, string, string, string, bool) error
+}
+
+// @Summary Get contacts
+// @Description Get the correspondents of an alias with their reply addresses
+// @Tags contact
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Alias ID"
+// @Success 200 {array} model.Contact
+// @Failure 400 {object} ErrorRes
+// @Router /alias/{id}/contacts [get]
+func (h *Handler) GetContacts(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	contacts, err := h.Service.GetContacts(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(contacts)
+}
+
+// @Summary Create contact
+// @Description Get or create the contact of an address, to compose a new message from the alias to its reply address
+// @Tags contact
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Alias ID"
+// @Param body body ContactComposeReq true "Contact compose request"
+// @Success 201 {object} model.Contact
+// @Failure 400 {object} ErrorRes
+// @Router /alias/{id}/contacts [post]
+func (h *Handler) PostContact(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := ContactComposeReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	contact, err := h.Service.PostContact(c.Context(), c.Params("id"), userID, req.Address)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(contact)
+}
+
+// @Summary Block or unblock contact
+// @Description Block or unblock mail from a contact of an alias
+// @Tags contact
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Alias ID"
+// @Param contact_id path string true "Contact ID"
+// @Param body body ContactReq true "Contact request"
+// @Success 200 {object} map[string]string "message"
+// @Failure 400 {object} ErrorRes
+// @Router /alias/{id}/contacts/{contact_id} [put]
+func (h *Handler) UpdateContact(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := ContactReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	err = h.Service.UpdateContactBlocked(c.Context(), c.Params("id"), c.Params("contact_id"), userID, req.Blocked)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrUpdateContact,
+		})
+	}
+
+	message := UnblockContactSuccess
+	if req.Blocked {
+		message = BlockContactSuccess
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+	})
+}
@@ -12,13 +12,16 @@ var (
 	ErrGetDomains                = "Unable to retrieve custom domains for this user."
 	ErrGetDomain                 = "Unable to retrieve custom domain for this user."
 	ErrGetDNSConfig              = "Unable to retrieve custom domains DNS config for this user."
+	ErrGetDKIMRecords            = "Unable to retrieve DKIM records."
 	ErrPostDomain                = "Unable to create custom domain. Please try again."
 	ErrUpdateDomain              = "Unable to update custom domain. Please try again."
 	ErrDeleteDomain              = "Unable to delete custom domain. Please try again."
+	ErrGetDomainKeys             = "Unable to retrieve DKIM keys for this domain."
 	PostDomainSuccess            = "Custom domain added successfully."
 	UpdateDomainSuccess          = "Custom domain updated successfully."
 	DeleteDomainSuccess          = "Custom domain deleted successfully."
 	DNSRecordVerificationSuccess = "Custom domain DNS records verified successfully."
+	PostDomainKeySuccess         = "DKIM key generated successfully. Publish its TXT record and verify the domain DNS records."
 )
 
 type DomainService interface {
@@ -28,10 +31,14 @@ type DomainService interface {
 	GetVerifiedDomain(context.Context, string, string) (model.Domain, error)
 	GetVerifiedDomainByName(context.Context, string) (model.Domain, error)
 	GetDNSConfig(context.Context, string) (model.DNSConfig, error)
+	GetDKIMRecords(context.Context) ([]model.DKIMRecord, error)
 	PostDomain(context.Context, model.Domain) (model.Domain, error)
 	UpdateDomain(context.Context, model.Domain) error
 	DeleteDomain(context.Context, string, string) error
 	VerifyDomainDNSRecords(context.Context, string, string) error
+	GetDomainKeys(context.Context, string, string) ([]model.DomainKey, error)
+	GenerateDomainKey(context.Context, string, string, string) (model.DomainKey, error)
+	RotateDomainKey(context.Context, string, string, string) (model.DomainKey, error)
 }
 
 // @Summary Get custom domains
@@ -43,6 +50,7 @@ type DomainService interface {
 // @Success 200 {array} model.Domain
 // @Failure 400 {object} ErrorRes
 // @Router /domains [get]
+// @Router /api/domains [get]
 func (h *Handler) GetDomains(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 	domains, err := h.Service.GetDomains(c.Context(), userID)
@@ -76,6 +84,26 @@ func (h *Handler) GetDNSConfig(c *fiber.Ctx) error {
 	return c.JSON(dnsConfig)
 }
 
+// @Summary Get DKIM records
+// @Description Get the DNS TXT records of the DKIM keys signing outbound mail
+// @Tags domain
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.DKIMRecord
+// @Failure 400 {object} ErrorRes
+// @Router /domains/dkim [get]
+func (h *Handler) GetDKIMRecords(c *fiber.Ctx) error {
+	records, err := h.Service.GetDKIMRecords(c.Context())
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetDKIMRecords,
+		})
+	}
+
+	return c.JSON(records)
+}
+
 // @Summary Create custom domain
 // @Description Create a new custom domain for the authenticated user
 // @Tags domain
@@ -218,7 +246,8 @@ func (h *Handler) DeleteDomain(c *fiber.Ctx) error {
 // @Param id path string true "Domain ID"
 // @Success 200 {object} map[string]string "message"
 // @Failure 400 {object} ErrorRes
-// @Router /domains/{id}/verify-dns [post]
+// @Router /domain/{id}/verify-dns [post]
+// @Router /api/domain/{id}/verify-dns [post]
 func (h *Handler) VerifyDomainDNSRecords(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 	domainID := c.Params("id")
@@ -235,6 +264,95 @@ func (h *Handler) VerifyDomainDNSRecords(c *fiber.Ctx) error {
 	})
 }
 
+// @Summary Get custom domain DKIM keys
+// @Description Get the generated DKIM keys of a custom domain and their TXT records
+// @Tags domain
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Domain ID"
+// @Success 200 {array} model.DomainKey
+// @Failure 400 {object} ErrorRes
+// @Router /domain/{id}/dkim [get]
+func (h *Handler) GetDomainKeys(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	domainID := c.Params("id")
+
+	keys, err := h.Service.GetDomainKeys(c.Context(), domainID, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetDomainKeys,
+		})
+	}
+
+	return c.JSON(keys)
+}
+
+// @Summary Generate custom domain DKIM key
+// @Description Generate a DKIM key for a custom domain, used for signing once its TXT record is verified
+// @Tags domain
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Domain ID"
+// @Param key body DomainKeyReq true "DKIM Key Request"
+// @Success 201 {object} model.DomainKey
+// @Failure 400 {object} ErrorRes
+// @Router /domain/{id}/dkim [post]
+func (h *Handler) GenerateDomainKey(c *fiber.Ctx) error {
+	return h.postDomainKey(c, h.Service.GenerateDomainKey)
+}
+
+// @Summary Rotate custom domain DKIM key
+// @Description Generate a new DKIM key for a custom domain, the current key signs until the new TXT record is verified
+// @Tags domain
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Domain ID"
+// @Param key body DomainKeyReq true "DKIM Key Request"
+// @Success 201 {object} model.DomainKey
+// @Failure 400 {object} ErrorRes
+// @Router /domain/{id}/dkim/rotate [post]
+func (h *Handler) RotateDomainKey(c *fiber.Ctx) error {
+	return h.postDomainKey(c, h.Service.RotateDomainKey)
+}
+
+func (h *Handler) postDomainKey(c *fiber.Ctx, generate func(context.Context, string, string, string) (model.DomainKey, error)) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	domainID := c.Params("id")
+	req := DomainKeyReq{}
+	if len(c.Body()) > 0 {
+		err := c.BodyParser(&req)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": ErrInvalidRequest,
+			})
+		}
+	}
+
+	// Validate the request
+	err := h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	key, err := generate(c.Context(), domainID, userID, req.Algorithm)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"key":     key,
+		"message": PostDomainKeySuccess,
+	})
+}
+
 // @Summary Check custom domain
 // @Description Check if a custom domain exists for the authenticated user
 // @Tags domain
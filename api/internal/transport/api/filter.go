@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetFilters       = "Unable to retrieve filters for this user."
+	ErrGetFilter        = "Unable to retrieve filter for this user."
+	ErrDeleteFilter     = "Unable to delete filter. Please try again."
+	PostFilterSuccess   = "Filter added successfully."
+	UpdateFilterSuccess = "Filter updated successfully."
+	DeleteFilterSuccess = "Filter deleted successfully."
+)
+
+type FilterService interface {
+	GetFilters(context.Context, string) ([]model.Filter, error)
+	GetFilter(context.Context, string, string) (model.Filter, error)
+	PostFilter(context.Context, model.Filter) (model.Filter, error)
+	UpdateFilter(context.Context, model.Filter) error
+	DeleteFilter(context.Context, string, string) error
+	DryRunFilters(context.Context, string, string, string, *model.Filter) (model.FilterResult, error)
+	ImportFilters(context.Context, string, string, string) (model.SieveImport, error)
+}
+
+// @Summary Get filters
+// @Description Get all filters for the authenticated user
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Filter
+// @Failure 400 {object} ErrorRes
+// @Router /filters [get]
+func (h *Handler) GetFilters(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	filters, err := h.Service.GetFilters(c.Context(), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetFilters,
+		})
+	}
+
+	return c.JSON(filters)
+}
+
+// @Summary Create filter
+// @Description Create a filter for an alias, or for all aliases when alias_id is empty
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body FilterReq true "Filter request"
+// @Success 201 {object} map[string]string "message"
+// @Failure 400 {object} ErrorRes
+// @Router /filter [post]
+func (h *Handler) PostFilter(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := FilterReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Post filter
+	filter := newFilter(req)
+	filter.UserID = userID
+
+	created, err := h.Service.PostFilter(c.Context(), filter)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":      created.ID,
+		"message": PostFilterSuccess,
+	})
+}
+
+// @Summary Update filter
+// @Description Update an existing filter for the authenticated user
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Filter ID"
+// @Param body body FilterReq true "Filter request"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /filter/{id} [put]
+func (h *Handler) UpdateFilter(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := FilterReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Get existing filter
+	existing, err := h.Service.GetFilter(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetFilter,
+		})
+	}
+
+	// Update filter
+	filter := newFilter(req)
+	filter.ID = existing.ID
+	filter.UserID = userID
+
+	err = h.Service.UpdateFilter(c.Context(), filter)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": UpdateFilterSuccess,
+	})
+}
+
+// @Summary Delete filter
+// @Description Delete an existing filter for the authenticated user
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Filter ID"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /filter/{id} [delete]
+func (h *Handler) DeleteFilter(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	err := h.Service.DeleteFilter(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrDeleteFilter,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": DeleteFilterSuccess,
+	})
+}
+
+// @Summary Dry-run filters
+// @Description Evaluate filters against a message stored with a log entry, without sending anything. Evaluates the given filter, or the saved filters of the alias (account filters when alias_id is empty).
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body FilterDryRunReq true "Filter dry-run request"
+// @Success 200 {object} model.FilterResult
+// @Failure 400 {object} ErrorRes
+// @Router /filters/dry-run [post]
+func (h *Handler) DryRunFilters(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := FilterDryRunReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	var filter *model.Filter
+	if req.Filter != nil {
+		f := newFilter(*req.Filter)
+		filter = &f
+	}
+
+	res, err := h.Service.DryRunFilters(c.Context(), userID, req.LogID, req.AliasID, filter)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(res)
+}
+
+// @Summary Import Sieve script
+// @Description Import a Sieve script (RFC 5228 subset) as filters of an alias. Unsupported constructs are skipped and reported by line.
+// @Tags filter
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body FilterImportReq true "Filter import request"
+// @Success 200 {object} model.SieveImport
+// @Failure 400 {object} ErrorRes
+// @Router /filters/import [post]
+func (h *Handler) ImportFilters(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := FilterImportReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	res, err := h.Service.ImportFilters(c.Context(), userID, req.AliasID, req.Script)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(res)
+}
+
+func newFilter(req FilterReq) model.Filter {
+	filter := model.Filter{
+		AliasID:  req.AliasID,
+		Name:     req.Name,
+		Match:    model.FilterMatch(req.Match),
+		Stop:     req.Stop,
+		Enabled:  req.Enabled,
+		Position: req.Position,
+	}
+
+	for _, c := range req.Conditions {
+		filter.Conditions = append(filter.Conditions, model.FilterCondition{
+			Field:    model.FilterField(c.Field),
+			Header:   c.Header,
+			Part:     model.FilterAddressPart(c.Part),
+			Operator: model.FilterOperator(c.Operator),
+			Value:    c.Value,
+			Values:   c.Values,
+			Negate:   c.Negate,
+		})
+	}
+
+	for _, a := range req.Actions {
+		filter.Actions = append(filter.Actions, model.FilterAction{
+			Type:  model.FilterActionType(a.Type),
+			Value: a.Value,
+		})
+	}
+
+	return filter
+}
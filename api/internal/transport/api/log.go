@@ -30,6 +30,7 @@ type LogService interface {
 // @Success 200 {array} model.Log
 // @Failure 400 {object} ErrorRes
 // @Router /logs [get]
+// @Router /api/logs [get]
 func (h *Handler) GetLogs(c *fiber.Ctx) error {
 	userId := auth.GetUserID(c)
 	logs, err := h.Service.GetLogs(c.Context(), userId)
@@ -52,6 +53,7 @@ func (h *Handler) GetLogs(c *fiber.Ctx) error {
 // @Success 200 {string} string "Log file content"
 // @Failure 400 {object} ErrorRes
 // @Router /log/file/{id} [get]
+// @Router /api/log/file/{id} [get]
 func (h *Handler) GetLogFile(c *fiber.Ctx) error {
 	userId := auth.GetUserID(c)
 	logId := c.Params("id")
@@ -2,22 +2,35 @@ package api
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/model"
+)
+
+const (
+	EnvelopeFromHeader = "X-Mailx-Envelope-From"
+	EnvelopeToHeader   = "X-Mailx-Envelope-To"
 )
 
 type ProcessorService interface {
-	ProcessMessage([]byte) error
+	ProcessMessage([]byte, model.Envelope) error
 }
 
 // @Summary Email handler
-// @Description Handle incoming email
+// @Description Handle incoming email. The SMTP envelope is passed in the X-Mailx-Envelope-From and X-Mailx-Envelope-To headers (or the sender and recipient query params) and is used for routing, falling back to the To header when missing.
 // @Tags email
 // @Accept json
 // @Produce json
+// @Param X-Mailx-Envelope-From header string false "Envelope sender (MAIL FROM)"
+// @Param X-Mailx-Envelope-To header string false "Comma separated envelope recipients (RCPT TO)"
+// @Param sender query string false "Envelope sender (MAIL FROM)"
+// @Param recipient query string false "Comma separated envelope recipients (RCPT TO)"
 // @Param email body string true "Email body"
 // @Success 200 {string} string "OK"
 // @Router /email [post]
 func (h *Handler) HandleEmail(c *fiber.Ctx) error {
-	err := h.Service.ProcessMessage(c.Body())
+	from := c.Get(EnvelopeFromHeader, c.Query("sender"))
+	to := c.Get(EnvelopeToHeader, c.Query("recipient"))
+
+	err := h.Service.ProcessMessage(c.Body(), model.NewEnvelope(from, to))
 	if err != nil {
 		// TEMPORARY failure → Postfix should retry
 		return c.Status(fiber.StatusServiceUnavailable).SendString("temporary failure")
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ReleaseQuarantineSuccess = "Message released successfully."
+	DeleteQuarantineSuccess  = "Message deleted successfully."
+)
+
+type QuarantineService interface {
+	GetQuarantine(context.Context, string) ([]model.Quarantine, error)
+	GetQuarantinePreview(context.Context, string, string) (model.QuarantinePreview, error)
+	ReleaseQuarantine(context.Context, string, string) error
+	DeleteQuarantine(context.Context, string, string) error
+}
+
+// @Summary Get quarantine
+// @Description Get quarantined messages of the authenticated user
+// @Tags quarantine
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Quarantine
+// @Failure 400 {object} ErrorRes
+// @Router /quarantine [get]
+func (h *Handler) GetQuarantine(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	messages, err := h.Service.GetQuarantine(c.Context(), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(messages)
+}
+
+// @Summary Preview quarantined message
+// @Description Get a quarantined message with its headers
+// @Tags quarantine
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Quarantined message ID"
+// @Success 200 {object} model.QuarantinePreview
+// @Failure 400 {object} ErrorRes
+// @Router /quarantine/{id} [get]
+func (h *Handler) GetQuarantinePreview(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	preview, err := h.Service.GetQuarantinePreview(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(preview)
+}
+
+// @Summary Release quarantined message
+// @Description Forward a quarantined message to the recipients of its alias
+// @Tags quarantine
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Quarantined message ID"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /quarantine/{id}/release [post]
+func (h *Handler) ReleaseQuarantine(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	err := h.Service.ReleaseQuarantine(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": ReleaseQuarantineSuccess,
+	})
+}
+
+// @Summary Delete quarantined message
+// @Description Delete a quarantined message
+// @Tags quarantine
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Quarantined message ID"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /quarantine/{id} [delete]
+func (h *Handler) DeleteQuarantine(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	err := h.Service.DeleteQuarantine(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": DeleteQuarantineSuccess,
+	})
+}
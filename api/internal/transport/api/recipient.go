@@ -60,6 +60,7 @@ func (h *Handler) GetRecipient(c *fiber.Ctx) error {
 // @Success 200 {array} model.Recipient
 // @Failure 400 {object} ErrorRes
 // @Router /recipients [get]
+// @Router /api/recipients [get]
 func (h *Handler) GetRecipients(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 	rcps, err := h.Service.GetRecipients(c.Context(), userID)
@@ -88,6 +89,7 @@ func (h *Handler) GetRecipients(c *fiber.Ctx) error {
 // @Success 201 {object} SuccessRes
 // @Failure 400 {object} ErrorRes
 // @Router /recipient [post]
+// @Router /api/recipient [post]
 func (h *Handler) PostRecipient(c *fiber.Ctx) error {
 	req := EmailReq{}
 	err := c.BodyParser(&req)
@@ -193,6 +195,7 @@ func (h *Handler) UpdateRecipient(c *fiber.Ctx) error {
 // @Success 200 {object} SuccessRes
 // @Failure 400 {object} ErrorRes
 // @Router /recipient/sendotp/{id} [post]
+// @Router /api/recipient/sendotp/{id} [post]
 func (h *Handler) SendRecipientOTP(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 	ID := c.Params("id")
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"time"
+
+	"ivpn.net/email/api/internal/model"
+)
+
 type UserReq struct {
 	Email    string `json:"email" validate:"required,emailx"`
 	Password string `json:"password" validate:"password"`
@@ -31,14 +37,18 @@ type SubscriptionReq struct {
 }
 
 type AliasReq struct {
-	Description       string `json:"description"`
-	Enabled           bool   `json:"enabled"`
-	Recipients        string `json:"recipients" validate:"required"`
-	FromName          string `json:"from_name"`
-	Format            string `json:"format"`
-	Domain            string `json:"domain" validate:"required"`
-	WildcardLocalPart string `json:"wildcard_local_part" validate:"omitempty,alphanum,min=6,max=12"`
-	LocalPart         string `json:"local_part" validate:"omitempty,emaillocalpart"`
+	Description       string               `json:"description"`
+	Enabled           bool                 `json:"enabled"`
+	Recipients        string               `json:"recipients" validate:"required"`
+	FromName          string               `json:"from_name"`
+	Format            string               `json:"format"`
+	Domain            string               `json:"domain" validate:"required"`
+	WildcardLocalPart string               `json:"wildcard_local_part" validate:"omitempty,alphanum,min=6,max=12"`
+	LocalPart         string               `json:"local_part" validate:"omitempty,emaillocalpart"`
+	SpamPolicy        *string              `json:"spam_policy" validate:"omitnil,oneof='' forward tag drop"`
+	ExpiresAt         *time.Time           `json:"expires_at"`
+	MaxMessages       *int                 `json:"max_messages" validate:"omitnil,min=0"`
+	Schedule          *model.AliasSchedule `json:"schedule"`
 }
 
 type RecipientReq struct {
@@ -53,13 +63,15 @@ type DeleteRecipientReq struct {
 }
 
 type SettingsReq struct {
-	ID           string `json:"id" validate:"required,uuid"`
-	Domain       string `json:"domain"`
-	Recipient    string `json:"recipient"`
-	FromName     string `json:"from_name"`
-	AliasFormat  string `json:"alias_format"`
-	LogIssues    bool   `json:"log_issues"`
-	RemoveHeader bool   `json:"remove_header"`
+	ID           string  `json:"id" validate:"required,uuid"`
+	Domain       string  `json:"domain"`
+	Recipient    string  `json:"recipient"`
+	FromName     string  `json:"from_name"`
+	AliasFormat  string  `json:"alias_format"`
+	LogIssues    bool    `json:"log_issues"`
+	RemoveHeader bool    `json:"remove_header"`
+	SpamPolicy   *string `json:"spam_policy" validate:"omitnil,oneof='' forward tag drop"`
+	Quarantine   bool    `json:"quarantine"`
 }
 
 type DeleteUserReq struct {
@@ -94,8 +106,10 @@ type RotatePASessionReq struct {
 }
 
 type AccessKeyReq struct {
-	Name      string `json:"name" validate:"required"`
-	ExpiresAt string `json:"expires_at"`
+	Name       string   `json:"name" validate:"required"`
+	ExpiresAt  string   `json:"expires_at"`
+	Scopes     []string `json:"scopes"`
+	AllowedIPs []string `json:"allowed_ips"`
 }
 
 type DomainReq struct {
@@ -110,3 +124,59 @@ type UpdateDomainReq struct {
 	Enabled     bool   `json:"enabled"`
 	CatchAll    bool   `json:"catch_all"`
 }
+
+type DomainKeyReq struct {
+	Algorithm string `json:"algorithm" validate:"omitempty,oneof=rsa ed25519"`
+}
+
+type ContactReq struct {
+	Blocked bool `json:"blocked"`
+}
+
+type ContactComposeReq struct {
+	Address string `json:"address" validate:"required,email"`
+}
+
+type RuleReq struct {
+	AliasID     string `json:"alias_id" validate:"omitempty,uuid"`
+	Pattern     string `json:"pattern" validate:"required,max=255"`
+	Action      string `json:"action" validate:"required,oneof=block allow"`
+	Description string `json:"description" validate:"max=255"`
+}
+
+type FilterConditionReq struct {
+	Field    string   `json:"field" validate:"required,oneof=subject header body attachment_size address envelope size"`
+	Header   string   `json:"header" validate:"max=255"`
+	Part     string   `json:"part" validate:"omitempty,oneof=all localpart domain"`
+	Operator string   `json:"operator" validate:"required,oneof=is contains matches regex exists over under"`
+	Value    string   `json:"value" validate:"max=1024"`
+	Values   []string `json:"values" validate:"max=20,dive,max=1024"`
+	Negate   bool     `json:"negate"`
+}
+
+type FilterActionReq struct {
+	Type  string `json:"type" validate:"required,oneof=drop forward_to tag_subject mark_spam"`
+	Value string `json:"value" validate:"max=255"`
+}
+
+type FilterReq struct {
+	AliasID    string               `json:"alias_id" validate:"omitempty,uuid"`
+	Name       string               `json:"name" validate:"max=255"`
+	Match      string               `json:"match" validate:"required,oneof=all any"`
+	Conditions []FilterConditionReq `json:"conditions" validate:"required,min=1,max=20,dive"`
+	Actions    []FilterActionReq    `json:"actions" validate:"required,min=1,max=10,dive"`
+	Stop       bool                 `json:"stop"`
+	Enabled    bool                 `json:"enabled"`
+	Position   int                  `json:"position"`
+}
+
+type FilterDryRunReq struct {
+	LogID   string     `json:"log_id" validate:"required,uuid"`
+	AliasID string     `json:"alias_id" validate:"omitempty,uuid"`
+	Filter  *FilterReq `json:"filter"`
+}
+
+type FilterImportReq struct {
+	AliasID string `json:"alias_id" validate:"required,uuid"`
+	Script  string `json:"script" validate:"required,max=65536"`
+}
@@ -11,6 +11,7 @@ import (
 	_ "ivpn.net/email/api/docs"
 	"ivpn.net/email/api/internal/middleware/auth"
 	"ivpn.net/email/api/internal/middleware/limit"
+	"ivpn.net/email/api/internal/model"
 )
 
 func (h *Handler) SetupRoutes(cfg config.APIConfig) {
@@ -44,10 +45,24 @@ func (h *Handler) SetupRoutes(cfg config.APIConfig) {
 
 	api := h.Server.Group("/v1/api")
 	api.Use(auth.NewAPIAuth(cfg, h.Service))
-	api.Get("/aliases", h.GetAliases)
-	api.Post("/alias", limiter.New(), h.PostAlias)
-	api.Put("/alias/:id", h.UpdateAlias)
-	api.Delete("/alias/:id", h.DeleteAlias)
+	api.Use(auth.NewAccessKeyAuth(h.Service))
+	api.Get("/aliases", auth.NewScope(model.ScopeAliasesRead), h.GetAliases)
+	api.Post("/alias", auth.NewScope(model.ScopeAliasesCreate), limiter.New(), h.PostAlias)
+	api.Put("/alias/:id", auth.NewScope(model.ScopeAliasesWrite), h.UpdateAlias)
+	api.Delete("/alias/:id", auth.NewScope(model.ScopeAliasesDelete), h.DeleteAlias)
+	api.Get("/rules", auth.NewScope(model.ScopeRulesRead), h.GetRules)
+	api.Post("/rule", auth.NewScope(model.ScopeRulesWrite), h.PostRule)
+	api.Put("/rule/:id", auth.NewScope(model.ScopeRulesWrite), h.UpdateRule)
+	api.Delete("/rule/:id", auth.NewScope(model.ScopeRulesWrite), h.DeleteRule)
+	api.Get("/recipients", auth.NewScope(model.ScopeRecipientsRead), h.GetRecipients)
+	api.Post("/recipient", auth.NewScope(model.ScopeRecipientsWrite), limit.New(5, 10*time.Minute), h.PostRecipient)
+	api.Post("/recipient/sendotp/:id", auth.NewScope(model.ScopeRecipientsWrite), limit.New(5, 10*time.Minute), h.SendRecipientOTP)
+	api.Get("/domains", auth.NewScope(model.ScopeDomainsRead), h.GetDomains)
+	api.Post("/domain/:id/verify-dns", auth.NewScope(model.ScopeDomainsWrite), h.VerifyDomainDNSRecords)
+	api.Get("/logs", auth.NewScope(model.ScopeLogsRead), h.GetLogs)
+	api.Get("/log/file/:id", auth.NewScope(model.ScopeLogsRead), h.GetLogFile)
+	api.Get("/settings", auth.NewScope(model.ScopeSettingsRead), h.GetSettings)
+	api.Put("/settings", auth.NewScope(model.ScopeSettingsWrite), h.UpdateSettings)
 	api.Get("/defaults", h.GetDefaults)
 	api.Post("/logout", h.ApiLogout)
 
@@ -63,6 +78,7 @@ func (h *Handler) SetupRoutes(cfg config.APIConfig) {
 	v1.Post("/user/delete", limit.New(5, 10*time.Minute), h.DeleteUser)
 	v1.Get("/user", h.GetUser)
 	v1.Get("/user/stats", h.GetUserStats)
+	v1.Get("/user/stats/series", h.GetUserStatsSeries)
 	v1.Get("/user/credentials", h.GetCredentials)
 	v1.Delete("/user/credential/:id", h.DeleteCredential)
 	v1.Put("/user/changepassword", limit.New(5, 10*time.Minute), h.ChangePassword)
@@ -70,6 +86,10 @@ func (h *Handler) SetupRoutes(cfg config.APIConfig) {
 	v1.Put("/user/totp/enable", limit.New(5, 10*time.Minute), h.TotpEnable)
 	v1.Put("/user/totp/enable/confirm", limit.New(5, 10*time.Minute), h.TotpEnableConfirm)
 	v1.Put("/user/totp/disable", limit.New(5, 10*time.Minute), h.TotpDisable)
+	v1.Get("/user/audit", h.GetAuditEvents)
+	v1.Get("/user/sessions", h.GetSessions)
+	v1.Delete("/user/sessions", h.DeleteOtherSessions)
+	v1.Delete("/user/sessions/:id", h.DeleteUserSession)
 
 	v1.Get("/sub", h.GetSubscription)
 	v1.Put("/sub/update", limiter.New(), h.UpdateSubscription)
@@ -87,27 +107,52 @@ func (h *Handler) SetupRoutes(cfg config.APIConfig) {
 	v1.Put("/recipient/delete/:id", h.DeleteRecipient)
 
 	v1.Get("/alias/:id", h.GetAlias)
+	v1.Get("/alias/:id/stats", h.GetAliasStatsSeries)
 	v1.Get("/aliases", h.GetAliases)
 	v1.Get("/aliases/export", h.ExportAliases)
 	v1.Post("/alias", limiter.New(), h.PostAlias)
 	v1.Put("/alias/:id", h.UpdateAlias)
 	v1.Delete("/alias/:id", h.DeleteAlias)
 	v1.Post("/alias/restore/:id", h.RestoreAlias)
+	v1.Get("/alias/:id/contacts", h.GetContacts)
+	v1.Post("/alias/:id/contacts", limiter.New(), h.PostContact)
+	v1.Put("/alias/:id/contacts/:contact_id", h.UpdateContact)
 
 	v1.Get("/logs", h.GetLogs)
 	v1.Delete("/logs", h.DeleteLogs)
 	v1.Get("/log/file/:id", h.GetLogFile)
 
+	v1.Get("/quarantine", h.GetQuarantine)
+	v1.Get("/quarantine/:id", h.GetQuarantinePreview)
+	v1.Post("/quarantine/:id/release", limiter.New(), h.ReleaseQuarantine)
+	v1.Delete("/quarantine/:id", h.DeleteQuarantine)
+
 	v1.Get("/accesskeys", h.GetAccessKeys)
 	v1.Post("/accesskeys", limiter.New(), h.PostAccessKey)
 	v1.Delete("/accesskeys/:id", h.DeleteAccessKey)
 
 	v1.Get("/domains", h.GetDomains)
 	v1.Get("/domains/dns-config", h.GetDNSConfig)
+	v1.Get("/domains/dkim", h.GetDKIMRecords)
 	v1.Post("/domain", limiter.New(), h.PostDomain)
 	v1.Put("/domain/:id", h.UpdateDomain)
 	v1.Delete("/domain/:id", h.DeleteDomain)
 	v1.Post("/domain/:id/verify-dns", h.VerifyDomainDNSRecords)
+	v1.Get("/domain/:id/dkim", h.GetDomainKeys)
+	v1.Post("/domain/:id/dkim", limiter.New(), h.GenerateDomainKey)
+	v1.Post("/domain/:id/dkim/rotate", limiter.New(), h.RotateDomainKey)
+
+	v1.Get("/rules", h.GetRules)
+	v1.Post("/rule", limiter.New(), h.PostRule)
+	v1.Put("/rule/:id", h.UpdateRule)
+	v1.Delete("/rule/:id", h.DeleteRule)
+
+	v1.Get("/filters", h.GetFilters)
+	v1.Post("/filters/dry-run", h.DryRunFilters)
+	v1.Post("/filters/import", limiter.New(), h.ImportFilters)
+	v1.Post("/filter", limiter.New(), h.PostFilter)
+	v1.Put("/filter/:id", h.UpdateFilter)
+	v1.Delete("/filter/:id", h.DeleteFilter)
 
 	docs := h.Server.Group("/docs")
 	docs.Use(auth.NewBasicAuth(cfg))
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrGetRules       = "Unable to retrieve sender rules for this user."
+	ErrGetRule        = "Unable to retrieve sender rule for this user."
+	ErrDeleteRule     = "Unable to delete sender rule. Please try again."
+	PostRuleSuccess   = "Sender rule added successfully."
+	UpdateRuleSuccess = "Sender rule updated successfully."
+	DeleteRuleSuccess = "Sender rule deleted successfully."
+)
+
+type RuleService interface {
+	GetRules(context.Context, string) ([]model.Rule, error)
+	GetRule(context.Context, string, string) (model.Rule, error)
+	PostRule(context.Context, model.Rule) (model.Rule, error)
+	UpdateRule(context.Context, model.Rule) error
+	DeleteRule(context.Context, string, string) error
+}
+
+// @Summary Get sender rules
+// @Description Get all sender block/allow rules for the authenticated user
+// @Tags rule
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Rule
+// @Failure 400 {object} ErrorRes
+// @Router /rules [get]
+// @Router /api/rules [get]
+func (h *Handler) GetRules(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	rules, err := h.Service.GetRules(c.Context(), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetRules,
+		})
+	}
+
+	return c.JSON(rules)
+}
+
+// @Summary Create sender rule
+// @Description Create a sender block/allow rule for an alias, or for all aliases when alias_id is empty
+// @Tags rule
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param body body RuleReq true "Sender rule request"
+// @Success 201 {object} map[string]string "message"
+// @Failure 400 {object} ErrorRes
+// @Router /rule [post]
+// @Router /api/rule [post]
+func (h *Handler) PostRule(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := RuleReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Post rule
+	rule := model.Rule{
+		UserID:      userID,
+		AliasID:     req.AliasID,
+		Pattern:     req.Pattern,
+		Action:      model.RuleAction(req.Action),
+		Description: req.Description,
+	}
+
+	created, err := h.Service.PostRule(c.Context(), rule)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"id":      created.ID,
+		"message": PostRuleSuccess,
+	})
+}
+
+// @Summary Update sender rule
+// @Description Update an existing sender block/allow rule for the authenticated user
+// @Tags rule
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Rule ID"
+// @Param body body RuleReq true "Sender rule request"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /rule/{id} [put]
+// @Router /api/rule/{id} [put]
+func (h *Handler) UpdateRule(c *fiber.Ctx) error {
+	// Parse the request
+	userID := auth.GetUserID(c)
+	req := RuleReq{}
+	err := c.BodyParser(&req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Validate the request
+	err = h.Validator.Struct(req)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidRequest,
+		})
+	}
+
+	// Get existing rule
+	rule, err := h.Service.GetRule(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrGetRule,
+		})
+	}
+
+	// Update rule
+	rule.AliasID = req.AliasID
+	rule.Pattern = req.Pattern
+	rule.Action = model.RuleAction(req.Action)
+	rule.Description = req.Description
+
+	err = h.Service.UpdateRule(c.Context(), rule)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": UpdateRuleSuccess,
+	})
+}
+
+// @Summary Delete sender rule
+// @Description Delete an existing sender block/allow rule for the authenticated user
+// @Tags rule
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Rule ID"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /rule/{id} [delete]
+// @Router /api/rule/{id} [delete]
+func (h *Handler) DeleteRule(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	err := h.Service.DeleteRule(c.Context(), c.Params("id"), userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrDeleteRule,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": DeleteRuleSuccess,
+	})
+}
@@ -23,6 +23,12 @@ type Service interface {
 	LogService
 	AccessKeyService
 	DomainService
+	RuleService
+	FilterService
+	StatsService
+	ContactService
+	QuarantineService
+	AuditEventService
 }
 
 type Handler struct {
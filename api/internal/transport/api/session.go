@@ -0,0 +1,79 @@
+package api
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+)
+
+// @Summary Get sessions
+// @Description Get signed in sessions of the authenticated user
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} model.Session
+// @Failure 400 {object} ErrorRes
+// @Router /user/sessions [get]
+func (h *Handler) GetSessions(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	sessions, err := h.Service.GetSessions(c.Context(), userID, auth.GetSessionID(c))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(sessions)
+}
+
+// @Summary Delete session
+// @Description Sign out a session of the authenticated user
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /user/sessions/{id} [delete]
+func (h *Handler) DeleteUserSession(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	ID := c.Params("id")
+	err := h.Service.DeleteSessionByID(c.Context(), ID, userID)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if ID == auth.GetSessionID(c) {
+		c.ClearCookie(auth.AUTHN_COOKIE)
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"message": DeleteSessionSuccess,
+	})
+}
+
+// @Summary Delete other sessions
+// @Description Sign out all sessions of the authenticated user except the current one
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessRes
+// @Failure 400 {object} ErrorRes
+// @Router /user/sessions [delete]
+func (h *Handler) DeleteOtherSessions(c *fiber.Ctx) error {
+	userID := auth.GetUserID(c)
+	err := h.Service.DeleteOtherSessions(c.Context(), userID, auth.GetSessionID(c))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(200).JSON(fiber.Map{
+		"message": DeleteSessionsSuccess,
+	})
+}
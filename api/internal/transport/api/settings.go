@@ -26,6 +26,7 @@ type SettingsService interface {
 // @Success 200 {object} model.Settings
 // @Failure 400 {object} ErrorRes
 // @Router /settings [get]
+// @Router /api/settings [get]
 func (h *Handler) GetSettings(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 
@@ -49,6 +50,7 @@ func (h *Handler) GetSettings(c *fiber.Ctx) error {
 // @Success 200 {object} SuccessRes
 // @Failure 400 {object} ErrorRes
 // @Router /settings [put]
+// @Router /api/settings [put]
 func (h *Handler) UpdateSettings(c *fiber.Ctx) error {
 	userID := auth.GetUserID(c)
 
@@ -75,9 +77,23 @@ func (h *Handler) UpdateSettings(c *fiber.Ctx) error {
 		AliasFormat:  req.AliasFormat,
 		LogIssues:    req.LogIssues,
 		RemoveHeader: req.RemoveHeader,
+		Quarantine:   req.Quarantine,
 	}
 	settings.ID = req.ID
 
+	// Keep the stored spam policy when the request leaves it out
+	if req.SpamPolicy != nil {
+		settings.SpamPolicy = model.SpamPolicy(*req.SpamPolicy)
+	} else {
+		current, err := h.Service.GetSettings(c.Context(), userID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		settings.SpamPolicy = current.SpamPolicy
+	}
+
 	err = h.Service.UpdateSettings(c.Context(), settings)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"ivpn.net/email/api/internal/middleware/auth"
+	"ivpn.net/email/api/internal/model"
+)
+
+var (
+	ErrInvalidStatsDate = "Please enter a valid date (YYYY-MM-DD or RFC 3339)."
+)
+
+type StatsService interface {
+	GetStatsSeries(context.Context, string, string, time.Time, time.Time, model.StatsBucket) (model.StatsSeries, error)
+}
+
+// @Summary Get alias stats
+// @Description Get alias message counts grouped by time bucket
+// @Tags alias
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Alias ID"
+// @Param from query string false "Start date (YYYY-MM-DD or RFC 3339), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD or RFC 3339), defaults to now"
+// @Param bucket query string false "Bucket (hour, day, week, month), defaults to day"
+// @Success 200 {object} model.StatsSeries
+// @Failure 400 {object} ErrorRes
+// @Router /alias/{id}/stats [get]
+func (h *Handler) GetAliasStatsSeries(c *fiber.Ctx) error {
+	return h.getStatsSeries(c, c.Params("id"))
+}
+
+// @Summary Get user stats series
+// @Description Get account message counts grouped by time bucket
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "Start date (YYYY-MM-DD or RFC 3339), defaults to 30 days ago"
+// @Param to query string false "End date (YYYY-MM-DD or RFC 3339), defaults to now"
+// @Param bucket query string false "Bucket (hour, day, week, month), defaults to day"
+// @Success 200 {object} model.StatsSeries
+// @Failure 400 {object} ErrorRes
+// @Router /user/stats/series [get]
+func (h *Handler) GetUserStatsSeries(c *fiber.Ctx) error {
+	return h.getStatsSeries(c, "")
+}
+
+func (h *Handler) getStatsSeries(c *fiber.Ctx, aliasID string) error {
+	userID := auth.GetUserID(c)
+
+	from, err := parseStatsDate(c.Query("from"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidStatsDate,
+		})
+	}
+
+	to, err := parseStatsDate(c.Query("to"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": ErrInvalidStatsDate,
+		})
+	}
+
+	bucket := model.StatsBucket(c.Query("bucket", string(model.BucketDay)))
+	series, err := h.Service.GetStatsSeries(c.Context(), userID, aliasID, from, to, bucket)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(series)
+}
+
+// parseStatsDate parses a date query parameter, an empty value yields the zero time.
+func parseStatsDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
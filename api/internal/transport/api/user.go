@@ -48,7 +48,7 @@ type UserService interface {
 	ChangePassword(context.Context, string, string) error
 	ChangeEmail(context.Context, string, string) error
 	InitiatePasswordReset(context.Context, string) error
-	ResetPassword(context.Context, string, string) error
+	ResetPassword(context.Context, string, string) (string, error)
 	TotpEnable(context.Context, string) (model.TOTPNew, error)
 	TotpEnableConfirm(context.Context, string, string) (model.TOTPBackup, error)
 	TotpDisable(context.Context, string, string) error
@@ -282,7 +282,7 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 			"error": ErrSaveSession,
 		})
 	}
-	err = h.Service.SaveSession(c.Context(), sessionData, token, user.ID, exp)
+	err = h.Service.SaveUserSession(c.Context(), sessionData, token, user.ID, c.IP(), c.Get(fiber.HeaderUserAgent), exp)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": ErrSaveSession,
@@ -292,6 +292,8 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 	// Set token in cookie
 	c.Cookie(auth.NewCookieAuthn(token, "/", h.Cfg))
 
+	h.audit(c, user.ID, model.AuditLogin, "")
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": LoginSuccess,
 	})
@@ -343,6 +345,8 @@ func (h *Handler) DeleteUserRequest(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, ID, model.AuditDeletionRequested, "")
+
 	return c.Status(200).JSON(fiber.Map{
 		"otp": otp,
 	})
@@ -475,6 +479,14 @@ func (h *Handler) ChangePassword(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, ID, model.AuditPasswordChanged, "")
+
+	// Sign out everywhere else
+	err = h.Service.DeleteOtherSessions(c.Context(), ID, auth.GetSessionID(c))
+	if err != nil {
+		log.Printf("error changing password: %s", err.Error())
+	}
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": ResetPasswordSuccess,
 	})
@@ -519,6 +531,14 @@ func (h *Handler) ChangeEmail(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, ID, model.AuditEmailChanged, req.Email)
+
+	// Sign out everywhere else
+	err = h.Service.DeleteOtherSessions(c.Context(), ID, auth.GetSessionID(c))
+	if err != nil {
+		log.Printf("error changing email: %s", err.Error())
+	}
+
 	// Send OTP
 	err = h.Service.SendUserOTP(c.Context(), ID)
 	if err != nil {
@@ -602,13 +622,15 @@ func (h *Handler) ResetPassword(c *fiber.Ctx) error {
 	}
 
 	// Reset the password
-	err = h.Service.ResetPassword(c.Context(), req.OTP, req.Password)
+	userID, err := h.Service.ResetPassword(c.Context(), req.OTP, req.Password)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	h.audit(c, userID, model.AuditPasswordChanged, "reset")
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": ResetPasswordSuccess,
 	})
@@ -674,6 +696,8 @@ func (h *Handler) TotpEnableConfirm(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, ID, model.AuditTotpEnabled, "")
+
 	return c.JSON(res)
 }
 
@@ -715,6 +739,8 @@ func (h *Handler) TotpDisable(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, ID, model.AuditTotpDisabled, "")
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": DisableTotpSuccess,
 	})
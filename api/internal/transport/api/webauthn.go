@@ -26,12 +26,20 @@ var (
 	ErrDeleteSession          = "Unable to delete session. Please try again."
 	ErrDeleteCredential       = "Unable to delete credential. Please try again."
 	DeleteCredentialSuccess   = "Credential deleted successfully."
+	DeleteSessionSuccess      = "Session signed out successfully."
+	DeleteSessionsSuccess     = "Signed out of all other sessions."
 )
 
 type SessionService interface {
 	GetSession(context.Context, string) (model.Session, bool, error)
 	SaveSession(context.Context, webauthn.SessionData, string, string, time.Time) error
+	SaveUserSession(context.Context, webauthn.SessionData, string, string, string, string, time.Time) error
+	SaveAccessKeySession(context.Context, webauthn.SessionData, string, string, string, string, string, time.Time) error
+	GetSessions(context.Context, string, string) ([]model.Session, error)
 	DeleteSession(context.Context, string) error
+	DeleteSessionByID(context.Context, string, string) error
+	DeleteOtherSessions(context.Context, string, string) error
+	TouchSession(context.Context, model.Session, string)
 }
 
 type CredentialService interface {
@@ -210,7 +218,7 @@ func (h *Handler) FinishRegistration(c *fiber.Ctx) error {
 			"error": ErrSaveSession,
 		})
 	}
-	err = h.Service.SaveSession(c.Context(), sessionData, token, user.ID, exp)
+	err = h.Service.SaveUserSession(c.Context(), sessionData, token, user.ID, c.IP(), c.Get(fiber.HeaderUserAgent), exp)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": ErrSaveSession,
@@ -324,6 +332,8 @@ func (h *Handler) FinishAddPasskey(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, user.ID, model.AuditPasskeyAdded, "")
+
 	// Delete session
 	err = h.Service.DeleteSession(c.Context(), token)
 	if err != nil {
@@ -347,7 +357,7 @@ func (h *Handler) FinishAddPasskey(c *fiber.Ctx) error {
 			"error": ErrSaveSession,
 		})
 	}
-	err = h.Service.SaveSession(c.Context(), sessionData, token, user.ID, exp)
+	err = h.Service.SaveUserSession(c.Context(), sessionData, token, user.ID, c.IP(), c.Get(fiber.HeaderUserAgent), exp)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": ErrSaveSession,
@@ -516,7 +526,7 @@ func (h *Handler) FinishLogin(c *fiber.Ctx) error {
 			"error": ErrSaveSession,
 		})
 	}
-	err = h.Service.SaveSession(c.Context(), sessionData, token, user.ID, exp)
+	err = h.Service.SaveUserSession(c.Context(), sessionData, token, user.ID, c.IP(), c.Get(fiber.HeaderUserAgent), exp)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": ErrSaveSession,
@@ -526,6 +536,8 @@ func (h *Handler) FinishLogin(c *fiber.Ctx) error {
 	// Set token in cookie
 	c.Cookie(auth.NewCookieAuthn(token, "/", h.Cfg))
 
+	h.audit(c, user.ID, model.AuditPasskeyLogin, "")
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": FinishLoginSuccess,
 	})
@@ -572,6 +584,8 @@ func (h *Handler) DeleteCredential(c *fiber.Ctx) error {
 		})
 	}
 
+	h.audit(c, userID, model.AuditPasskeyDeleted, "")
+
 	return c.Status(200).JSON(fiber.Map{
 		"message": DeleteCredentialSuccess,
 	})
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// arcHeaders are the fields covered by ARC-Message-Signature when present.
+var arcHeaders = []string{
+	"From", "To", "Cc", "Subject", "Date", "Message-ID", "In-Reply-To",
+	"References", "MIME-Version", "Content-Type", "Content-Transfer-Encoding",
+}
+
+// SealARC returns the ARC set (RFC 8617) of a message we relay after
+// rewriting it: ARC-Seal, ARC-Message-Signature and ARC-Authentication-Results
+// with instance 1. The upstream chain is not carried over, as the rewritten
+// message no longer validates against it. authResults is the verdict of our
+// MTA in Authentication-Results format, authserv-id included.
+func SealARC(msg []byte, key DKIMKey, authResults string, t time.Time) ([]byte, error) {
+	fields, body, err := splitMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	authResults = strings.Join(strings.Fields(authResults), " ")
+	if authResults == "" {
+		authResults = key.Domain + "; none"
+	}
+	aar := "ARC-Authentication-Results: i=1; " + authResults + "\r\n"
+
+	names, headers := signedHeaders(fields, arcHeaders)
+	ams, err := key.signField(headers, "ARC-Message-Signature", fmt.Sprintf(
+		"i=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s;",
+		key.Algorithm(), key.Domain, key.Selector, t.Unix(), strings.Join(names, ":"), bodyHash(body),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	seal, err := key.signField(relaxedHeader(aar)+relaxedHeader(ams), "ARC-Seal", fmt.Sprintf(
+		"i=1; a=%s; t=%d; cv=none; d=%s; s=%s;",
+		key.Algorithm(), t.Unix(), key.Domain, key.Selector,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(seal + ams + aar), nil
+}
@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMessage = "From: John <john@example.com>\r\n" +
+	"To: jane@example.net\r\n" +
+	"Subject: Hello\r\n" +
+	"  world\r\n" +
+	"Date: Sun, 18 Oct 2026 12:00:00 +0000\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Hello  there \r\n" +
+	"\r\n\r\n"
+
+// verifyField checks the b= signature of a signature field over headers.
+func verifyField(t *testing.T, pub crypto.PublicKey, headers string, field string) {
+	t.Helper()
+
+	i := strings.LastIndex(field, "b=")
+	sig, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(field[i+2:]), ""))
+	if err != nil {
+		t.Fatalf("invalid signature encoding: %v", err)
+	}
+
+	data := headers + strings.TrimSuffix(relaxedHeader(field[:i+2]), "\r\n")
+	hash := sha256.Sum256([]byte(data))
+
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig); err != nil {
+			t.Errorf("invalid RSA signature: %v", err)
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, hash[:], sig) {
+			t.Error("invalid Ed25519 signature")
+		}
+	}
+}
+
+func TestSealARC(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, signer := range []crypto.Signer{rsaKey, edKey} {
+		key := DKIMKey{Domain: "mailx.net", Selector: "arc", Signer: signer}
+		set, err := SealARC([]byte(testMessage), key, "mx.mailx.net; dkim=pass header.d=example.com", time.Unix(1791979200, 0))
+		if err != nil {
+			t.Fatalf("SealARC() error = %v", err)
+		}
+
+		fields, _, err := splitMessage(append(set, testMessage...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		seal, ams, aar := fields[0], fields[1], fields[2]
+
+		if !strings.HasPrefix(seal, "ARC-Seal: i=1; a="+key.Algorithm()) || !strings.Contains(seal, "cv=none") {
+			t.Errorf("unexpected seal %q", seal)
+		}
+		if aar != "ARC-Authentication-Results: i=1; mx.mailx.net; dkim=pass header.d=example.com\r\n" {
+			t.Errorf("unexpected AAR %q", aar)
+		}
+		if !strings.Contains(ams, "h=from:to:subject:date:content-type;") {
+			t.Errorf("unexpected signed headers %q", ams)
+		}
+
+		// "Hello there\r\n" once canonicalized
+		hash := sha256.Sum256([]byte("Hello there\r\n"))
+		if !strings.Contains(ams, "bh="+base64.StdEncoding.EncodeToString(hash[:])+";") {
+			t.Errorf("unexpected body hash %q", ams)
+		}
+
+		_, headers := signedHeaders(fields[3:], arcHeaders)
+		verifyField(t, signer.Public(), headers, ams)
+		verifyField(t, signer.Public(), relaxedHeader(aar)+relaxedHeader(ams), seal)
+	}
+}
@@ -0,0 +1,313 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidDKIMKey       = errors.New("invalid DKIM private key, expected RSA or Ed25519 in PEM format")
+	ErrInvalidDKIMAlgorithm = errors.New("invalid DKIM key algorithm, expected rsa or ed25519")
+	ErrInvalidMessage       = errors.New("invalid message, missing header and body separator")
+)
+
+// dkimHeaders are the fields covered by DKIM-Signature when present.
+var dkimHeaders = []string{
+	"From", "Reply-To", "To", "Cc", "Subject", "Date", "Message-ID", "In-Reply-To",
+	"References", "MIME-Version", "Content-Type", "Content-Transfer-Encoding", "Feedback-ID",
+}
+
+// DKIMKey is a private key published under selector._domainkey.domain, used
+// to sign DKIM-Signature and ARC headers.
+type DKIMKey struct {
+	Domain   string
+	Selector string
+	Signer   crypto.Signer
+}
+
+// ParseDKIMKey parses a PKCS#1 or PKCS#8 PEM encoded RSA or Ed25519 private key.
+func ParseDKIMKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidDKIMKey
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, ErrInvalidDKIMKey
+	}
+
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	}
+
+	return nil, ErrInvalidDKIMKey
+}
+
+// LoadDKIMKey reads the private key file of a DKIM key.
+func LoadDKIMKey(domain string, selector string, path string) (*DKIMKey, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ParseDKIMKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DKIMKey{
+		Domain:   domain,
+		Selector: selector,
+		Signer:   signer,
+	}, nil
+}
+
+// Algorithm returns the a= tag value of the key.
+func (k DKIMKey) Algorithm() string {
+	if _, ok := k.Signer.(ed25519.PrivateKey); ok {
+		return "ed25519-sha256"
+	}
+
+	return "rsa-sha256"
+}
+
+// TXTRecord returns the DNS TXT value to publish at Name.
+func (k DKIMKey) TXTRecord() (string, error) {
+	switch pub := k.Signer.Public().(type) {
+	case ed25519.PublicKey:
+		return "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(pub), nil
+	case *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return "", err
+		}
+		return "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der), nil
+	}
+
+	return "", ErrInvalidDKIMKey
+}
+
+// Name returns the DNS name the key is published at.
+func (k DKIMKey) Name() string {
+	return k.Selector + "._domainkey." + k.Domain
+}
+
+// GenerateDKIMKey returns a new PKCS#8 PEM encoded private key, RSA keys are 2048 bits.
+func GenerateDKIMKey(algorithm string) ([]byte, error) {
+	var key crypto.Signer
+	var err error
+	switch algorithm {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrInvalidDKIMAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// SignDKIM returns the DKIM-Signature field (RFC 6376) of a message, with
+// relaxed/relaxed canonicalization.
+func SignDKIM(msg []byte, key DKIMKey, t time.Time) ([]byte, error) {
+	fields, body, err := splitMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	names, headers := signedHeaders(fields, dkimHeaders)
+	field, err := key.signField(headers, "DKIM-Signature", fmt.Sprintf(
+		"v=1; a=%s; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s;",
+		key.Algorithm(), key.Domain, key.Selector, t.Unix(), strings.Join(names, ":"), bodyHash(body),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(field), nil
+}
+
+// sign returns the base64 signature of the SHA-256 hash of data (RFC 6376, RFC 8463).
+func (k DKIMKey) sign(data []byte) (string, error) {
+	hash := sha256.Sum256(data)
+
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := k.Signer.(ed25519.PrivateKey); ok {
+		opts = crypto.Hash(0)
+	}
+
+	sig, err := k.Signer.Sign(rand.Reader, hash[:], opts)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// splitMessage splits a CRLF message into its header fields, continuation
+// lines included, and body.
+func splitMessage(msg []byte) ([]string, []byte, error) {
+	i := bytes.Index(msg, []byte("\r\n\r\n"))
+	if i < 0 {
+		return nil, nil, ErrInvalidMessage
+	}
+
+	fields := []string{}
+	for _, line := range strings.SplitAfter(string(msg[:i+2]), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1] += line
+			continue
+		}
+		fields = append(fields, line)
+	}
+
+	return fields, msg[i+4:], nil
+}
+
+// headerName returns the lowercase name of a header field.
+func headerName(field string) string {
+	name, _, _ := strings.Cut(field, ":")
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// relaxedHeader canonicalizes a header field with the "relaxed" algorithm (RFC 6376, 3.4.2).
+func relaxedHeader(field string) string {
+	name, value, _ := strings.Cut(field, ":")
+	value = strings.ReplaceAll(value, "\r\n", "")
+	value = strings.Join(strings.FieldsFunc(value, func(r rune) bool {
+		return r == ' ' || r == '\t'
+	}), " ")
+
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// relaxedBody canonicalizes a body with the "relaxed" algorithm (RFC 6376, 3.4.4).
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+
+	var buf bytes.Buffer
+	empty := 0
+	for _, line := range lines {
+		line = relaxedLine(line)
+		if line == "" {
+			empty++
+			continue
+		}
+
+		for ; empty > 0; empty-- {
+			buf.WriteString("\r\n")
+		}
+		buf.WriteString(line + "\r\n")
+	}
+
+	return buf.Bytes()
+}
+
+// relaxedLine reduces each run of whitespace in a body line to a single space
+// and removes the trailing whitespace. Leading whitespace is kept as one space.
+func relaxedLine(line string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range line {
+		if r == ' ' || r == '\t' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// bodyHash returns the base64 SHA-256 hash of the relaxed body.
+func bodyHash(body []byte) string {
+	hash := sha256.Sum256(relaxedBody(body))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// signedHeaders returns the names of the fields present in the message among
+// names, and their relaxed canonical form. Fields repeated in names are
+// selected from the bottom up as in RFC 6376, 5.4.2.
+func signedHeaders(fields []string, names []string) ([]string, string) {
+	used := map[int]bool{}
+	signed := []string{}
+
+	var buf strings.Builder
+	for _, name := range names {
+		name = strings.ToLower(name)
+		for i := len(fields) - 1; i >= 0; i-- {
+			if used[i] || headerName(fields[i]) != name {
+				continue
+			}
+
+			used[i] = true
+			signed = append(signed, name)
+			buf.WriteString(relaxedHeader(fields[i]))
+			break
+		}
+	}
+
+	return signed, buf.String()
+}
+
+// signField signs the canonical headers followed by the signature field with
+// an empty b= tag, and returns the field with the signature filled in.
+func (k DKIMKey) signField(headers string, name string, tags string) (string, error) {
+	field := name + ": " + tags + " b="
+	data := headers + strings.TrimSuffix(relaxedHeader(field), "\r\n")
+
+	sig, err := k.sign([]byte(data))
+	if err != nil {
+		return "", err
+	}
+
+	return field + foldSignature(sig) + "\r\n", nil
+}
+
+// foldSignature folds a base64 signature over several lines.
+func foldSignature(sig string) string {
+	var b strings.Builder
+	for len(sig) > 72 {
+		b.WriteString(sig[:72] + "\r\n\t")
+		sig = sig[72:]
+	}
+	b.WriteString(sig)
+
+	return b.String()
+}
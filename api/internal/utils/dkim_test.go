@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignDKIM(t *testing.T) {
+	for _, algorithm := range []string{"rsa", "ed25519"} {
+		t.Run(algorithm, func(t *testing.T) {
+			data, err := GenerateDKIMKey(algorithm)
+			if err != nil {
+				t.Fatalf("GenerateDKIMKey() error = %v", err)
+			}
+
+			signer, err := ParseDKIMKey(data)
+			if err != nil {
+				t.Fatalf("ParseDKIMKey() error = %v", err)
+			}
+
+			key := DKIMKey{Domain: "example.net", Selector: "mx1", Signer: signer}
+			field, err := SignDKIM([]byte(testMessage), key, time.Unix(1791979200, 0))
+			if err != nil {
+				t.Fatalf("SignDKIM() error = %v", err)
+			}
+
+			sig := string(field)
+			if !strings.HasPrefix(sig, "DKIM-Signature: v=1; a="+algorithm+"-sha256; c=relaxed/relaxed; d=example.net; s=mx1; t=1791979200;") {
+				t.Errorf("unexpected signature %q", sig)
+			}
+
+			fields, _, err := splitMessage([]byte(testMessage))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, headers := signedHeaders(fields, dkimHeaders)
+			verifyField(t, signer.Public(), headers, sig)
+
+			txt, err := key.TXTRecord()
+			if err != nil || !strings.HasPrefix(txt, "v=DKIM1; k="+algorithm+"; p=") {
+				t.Errorf("unexpected TXT record %q, %v", txt, err)
+			}
+			if key.Name() != "mx1._domainkey.example.net" {
+				t.Errorf("unexpected name %s", key.Name())
+			}
+		})
+	}
+
+	if _, err := GenerateDKIMKey("dsa"); err != ErrInvalidDKIMAlgorithm {
+		t.Errorf("expected ErrInvalidDKIMAlgorithm, got %v", err)
+	}
+}
+
+func TestRelaxedHeader(t *testing.T) {
+	got := relaxedHeader("Subject:  Hello\r\n \t world  \r\n")
+	if got != "subject:Hello world\r\n" {
+		t.Errorf("relaxedHeader() = %q", got)
+	}
+}
+
+func TestRelaxedBody(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"", ""},
+		{"\r\n\r\n", ""},
+		{"a  b \r\n\r\nc\t\r\n\r\n", "a b\r\n\r\nc\r\n"},
+		{"no newline", "no newline\r\n"},
+		{" C \r\nD \t E\r\n", " C\r\nD E\r\n"},
+		{"> quoted\r\n\t  indented\r\n", "> quoted\r\n indented\r\n"},
+	}
+
+	for _, tt := range tests {
+		if got := string(relaxedBody([]byte(tt.body))); got != tt.want {
+			t.Errorf("relaxedBody(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestParseDKIMKey(t *testing.T) {
+	if _, err := ParseDKIMKey([]byte("not a key")); err != ErrInvalidDKIMKey {
+		t.Errorf("expected ErrInvalidDKIMKey, got %v", err)
+	}
+}
+
+// rfc8463Message is the signed example message of RFC 8463, appendix A.3.
+const rfc8463Message = "DKIM-Signature: v=1; a=ed25519-sha256; c=relaxed/relaxed;\r\n" +
+	" d=football.example.com; i=@football.example.com;\r\n" +
+	" q=dns/txt; s=brisbane; t=1528637909; h=from : to :\r\n" +
+	" subject : date : message-id : from : subject : date;\r\n" +
+	" bh=2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=;\r\n" +
+	" b=/gCrinpcQOoIfuHNQIbq4pgh9kyIK3AQUdt9OdqQehSwhEIug4D11Bus\r\n" +
+	" Fa3bT3FY5OsU7ZbnKELq+eXdp1Q1Dw==\r\n" +
+	"From: Joe SixPack <joe@football.example.com>\r\n" +
+	"To: Suzie Q <suzie@shopping.example.net>\r\n" +
+	"Subject: Is dinner ready?\r\n" +
+	"Date: Fri, 11 Jul 2003 21:00:37 -0700 (PDT)\r\n" +
+	"Message-ID: <20030712040037.46341.5F8J@football.example.com>\r\n" +
+	"\r\n" +
+	"Hi.\r\n" +
+	"\r\n" +
+	"We lost the game.  Are you hungry yet?\r\n" +
+	"\r\n" +
+	"Joe.\r\n"
+
+func TestDKIMKnownSignature(t *testing.T) {
+	fields, body, err := splitMessage([]byte(rfc8463Message))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := bodyHash(body); got != "2jUSOH9NhtVGCQWNr9BrIAPreKQjO6Sn7XIkfJVOzv8=" {
+		t.Errorf("bodyHash() = %s", got)
+	}
+
+	pub, err := base64.StdEncoding.DecodeString("11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo=")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"from", "to", "subject", "date", "message-id", "from", "subject", "date"}
+	_, headers := signedHeaders(fields[1:], names)
+	verifyField(t, ed25519.PublicKey(pub), headers, fields[0])
+}
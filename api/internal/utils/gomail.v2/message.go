@@ -11,15 +11,22 @@ import (
 
 // Message represents an email.
 type Message struct {
-	header      header
-	parts       []*part
-	attachments []*file
-	embedded    []*file
-	charset     string
-	encoding    Encoding
-	hEncoder    mimeEncoder
-	buf         bytes.Buffer
-}
+	header       header
+	parts        []*part
+	attachments  []*file
+	embedded     []*file
+	charset      string
+	encoding     Encoding
+	hEncoder     mimeEncoder
+	buf          bytes.Buffer
+	envelopeFrom string
+	envelopeTo   []string
+	signer       Signer
+}
+
+// A Signer returns header fields to prepend to the rendered message, such as
+// DKIM or ARC signatures. Each field must end with CRLF.
+type Signer func(msg []byte) ([]byte, error)
 
 type header map[string][]string
 
@@ -77,6 +84,9 @@ func (m *Message) Reset() {
 	m.parts = nil
 	m.attachments = nil
 	m.embedded = nil
+	m.envelopeFrom = ""
+	m.envelopeTo = nil
+	m.signer = nil
 }
 
 func (m *Message) applySettings(settings []MessageSetting) {
@@ -117,6 +127,23 @@ const (
 	Unencoded Encoding = "8bit"
 )
 
+// SetEnvelopeFrom sets the SMTP envelope sender (MAIL FROM), which otherwise
+// is taken from the "Sender" or "From" header.
+func (m *Message) SetEnvelopeFrom(address string) {
+	m.envelopeFrom = address
+}
+
+// SetEnvelopeTo sets the SMTP envelope recipients (RCPT TO), which otherwise
+// are taken from the "To", "Cc" and "Bcc" headers.
+func (m *Message) SetEnvelopeTo(addresses ...string) {
+	m.envelopeTo = addresses
+}
+
+// SetSigner sets the function signing the message when it is sent.
+func (m *Message) SetSigner(signer Signer) {
+	m.signer = signer
+}
+
 // SetHeader sets a value to the given header field.
 func (m *Message) SetHeader(field string, value ...string) {
 	m.encodeHeader(value)
@@ -1,6 +1,7 @@
 package gomail
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
@@ -37,7 +38,7 @@ func (f SendFunc) Send(from string, to []string, msg io.WriterTo) error {
 func Send(s Sender, msg ...*Message) error {
 	for i, m := range msg {
 		if err := send(s, m); err != nil {
-			return fmt.Errorf("gomail: could not send email %d: %v", i+1, err)
+			return fmt.Errorf("gomail: could not send email %d: %w", i+1, err)
 		}
 	}
 
@@ -55,6 +56,15 @@ func send(s Sender, m *Message) error {
 		return err
 	}
 
+	if m.signer != nil {
+		signed, err := m.sign()
+		if err != nil {
+			return err
+		}
+
+		return s.Send(from, to, signed)
+	}
+
 	if err := s.Send(from, to, m); err != nil {
 		return err
 	}
@@ -62,7 +72,26 @@ func send(s Sender, m *Message) error {
 	return nil
 }
 
+// sign renders the message and prepends the header fields of the signer.
+func (m *Message) sign() (io.WriterTo, error) {
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	fields, err := m.signer(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("gomail: could not sign email: %w", err)
+	}
+
+	return bytes.NewReader(append(fields, buf.Bytes()...)), nil
+}
+
 func (m *Message) getFrom() (string, error) {
+	if m.envelopeFrom != "" {
+		return parseAddress(m.envelopeFrom)
+	}
+
 	from := m.header["Sender"]
 	if len(from) == 0 {
 		from = m.header["From"]
@@ -75,6 +104,19 @@ func (m *Message) getFrom() (string, error) {
 }
 
 func (m *Message) getRecipients() ([]string, error) {
+	if len(m.envelopeTo) > 0 {
+		list := make([]string, 0, len(m.envelopeTo))
+		for _, a := range m.envelopeTo {
+			addr, err := parseAddress(a)
+			if err != nil {
+				return nil, err
+			}
+			list = addAddress(list, addr)
+		}
+
+		return list, nil
+	}
+
 	n := 0
 	for _, field := range []string{"To", "Cc", "Bcc"} {
 		if addresses, ok := m.header[field]; ok {
@@ -176,6 +176,11 @@ func (c *smtpSender) Send(from string, to []string, msg io.WriterTo) error {
 	return w.Close()
 }
 
+// Noop checks that the connection to the server is still alive.
+func (c *smtpSender) Noop() error {
+	return c.smtpClient.Noop()
+}
+
 func (c *smtpSender) Close() error {
 	return c.Quit()
 }
@@ -197,6 +202,7 @@ type smtpClient interface {
 	Mail(string) error
 	Rcpt(string) error
 	Data() (io.WriteCloser, error)
+	Noop() error
 	Quit() error
 	Close() error
 }
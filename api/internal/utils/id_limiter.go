@@ -10,7 +10,7 @@ import (
 type Cache interface {
 	Set(context.Context, string, any, time.Duration) error
 	Get(context.Context, string) (string, error)
-	Incr(context.Context, string, time.Duration) error
+	Incr(context.Context, string, time.Duration) (int64, error)
 }
 
 type IDLimiter struct {
@@ -22,7 +22,7 @@ type IDLimiter struct {
 }
 
 func (l *IDLimiter) Tick() error {
-	err := l.Cache.Incr(context.Background(), l.Label+"_"+l.ID, l.Exp)
+	_, err := l.Cache.Incr(context.Background(), l.Label+"_"+l.ID, l.Exp)
 	if err != nil {
 		log.Printf("error setting failed attempts: %s", err.Error())
 		return err
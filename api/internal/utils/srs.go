@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // #nosec G505 -- SRS hashes are HMAC-SHA1 for interoperability
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSRSAddress = errors.New("invalid SRS address")
+	ErrSRSHash    = errors.New("invalid SRS hash")
+	ErrSRSExpired = errors.New("expired SRS timestamp")
+)
+
+const (
+	srsHashLength = 4
+	srsTimeBase   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	srsTimeSlots  = 1024 // two base32 characters
+	srsDay        = 24 * time.Hour
+)
+
+// SRS rewrites envelope senders with the Sender Rewriting Scheme, so that
+// forwarded mail passes SPF and bounces come back to Domain:
+//
+//	SRS0=HHHH=TT=example.com=john@Domain
+//	SRS1=HHHH=forwarder.net==HHHH=TT=example.com=john@Domain
+type SRS struct {
+	Secret string
+	Domain string
+	MaxAge time.Duration
+}
+
+// IsSRS reports whether the address local part is SRS0 or SRS1.
+func IsSRS(address string) bool {
+	local := strings.ToUpper(address)
+	return strings.HasPrefix(local, "SRS0") || strings.HasPrefix(local, "SRS1")
+}
+
+// Forward returns the SRS address of sender. Senders of Domain are kept,
+// SRS0 addresses of other forwarders are rewritten to SRS1.
+func (s SRS) Forward(sender string, now time.Time) (string, error) {
+	local, domain, ok := cutAddress(sender)
+	if !ok {
+		return "", ErrSRSAddress
+	}
+
+	if strings.EqualFold(domain, s.Domain) {
+		return sender, nil
+	}
+
+	// SRS1=HHHH=host==HHHH=TT=domain=local, only the hash changes
+	if isSRSPrefix(local, "SRS1") {
+		_, rest, ok := strings.Cut(local[5:], "=")
+		host, user, ok2 := strings.Cut(rest, "=")
+		if !ok || !ok2 || host == "" || user == "" {
+			return "", ErrSRSAddress
+		}
+
+		return "SRS1=" + s.hash(host+user) + "=" + host + "=" + user + "@" + s.Domain, nil
+	}
+
+	if isSRSPrefix(local, "SRS0") {
+		user := local[4:]
+		return "SRS1=" + s.hash(domain+user) + "=" + domain + "=" + user + "@" + s.Domain, nil
+	}
+
+	ts := srsTimestamp(now)
+	return "SRS0=" + s.hash(ts+domain+local) + "=" + ts + "=" + domain + "=" + local + "@" + s.Domain, nil
+}
+
+// Reverse verifies an SRS address of Domain and returns the address the
+// bounce is to be delivered to.
+func (s SRS) Reverse(address string, now time.Time) (string, error) {
+	local, domain, ok := cutAddress(address)
+	if !ok || !strings.EqualFold(domain, s.Domain) {
+		return "", ErrSRSAddress
+	}
+
+	if isSRSPrefix(local, "SRS1") {
+		hash, rest, ok := strings.Cut(local[5:], "=")
+		if !ok {
+			return "", ErrSRSAddress
+		}
+		host, user, ok := strings.Cut(rest, "=")
+		if !ok || host == "" || user == "" {
+			return "", ErrSRSAddress
+		}
+		if !s.verify(hash, host+user) {
+			return "", ErrSRSHash
+		}
+
+		return "SRS0" + user + "@" + host, nil
+	}
+
+	if !isSRSPrefix(local, "SRS0") {
+		return "", ErrSRSAddress
+	}
+
+	parts := strings.SplitN(local[5:], "=", 4)
+	if len(parts) != 4 || parts[2] == "" || parts[3] == "" {
+		return "", ErrSRSAddress
+	}
+	hash, ts, host, user := parts[0], parts[1], parts[2], parts[3]
+
+	if !s.verify(hash, ts+host+user) {
+		return "", ErrSRSHash
+	}
+
+	if !s.fresh(ts, now) {
+		return "", ErrSRSExpired
+	}
+
+	return user + "@" + host, nil
+}
+
+func (s SRS) hash(data string) string {
+	mac := hmac.New(sha1.New, []byte(s.Secret))
+	mac.Write([]byte(strings.ToLower(data)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))[:srsHashLength]
+}
+
+// verify compares hashes case-insensitively, as some MTAs lowercase addresses.
+func (s SRS) verify(hash string, data string) bool {
+	return len(hash) == srsHashLength && hmac.Equal([]byte(strings.ToLower(hash)), []byte(strings.ToLower(s.hash(data))))
+}
+
+// fresh reports whether the timestamp is within MaxAge days of now.
+func (s SRS) fresh(ts string, now time.Time) bool {
+	if len(ts) != 2 {
+		return false
+	}
+
+	value := 0
+	for _, c := range strings.ToUpper(ts) {
+		i := strings.IndexRune(srsTimeBase, c)
+		if i < 0 {
+			return false
+		}
+		value = value<<5 | i
+	}
+
+	maxAge := s.MaxAge
+	if maxAge == 0 {
+		maxAge = 21 * srsDay
+	}
+
+	today := int(now.Unix()/int64(srsDay.Seconds())) % srsTimeSlots
+	age := (today - value + srsTimeSlots) % srsTimeSlots
+
+	return time.Duration(age)*srsDay <= maxAge
+}
+
+func srsTimestamp(now time.Time) string {
+	days := int(now.Unix()/int64(srsDay.Seconds())) % srsTimeSlots
+	return string([]byte{srsTimeBase[days>>5], srsTimeBase[days&31]})
+}
+
+func isSRSPrefix(local string, prefix string) bool {
+	return len(local) > 5 && strings.EqualFold(local[:4], prefix) && strings.ContainsRune("=+-", rune(local[4]))
+}
+
+func cutAddress(address string) (string, string, bool) {
+	address = strings.Trim(strings.TrimSpace(address), "<>")
+	i := strings.LastIndex(address, "@")
+	if i <= 0 || i == len(address)-1 {
+		return "", "", false
+	}
+
+	return address[:i], address[i+1:], true
+}
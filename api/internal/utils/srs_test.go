@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSRSForwardReverse(t *testing.T) {
+	srs := SRS{Secret: "secret", Domain: "mailx.net"}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		sender  string
+		prefix  string
+		reverse string
+	}{
+		{"plain sender", "john@example.com", "SRS0=", "john@example.com"},
+		{"own domain", "alias@mailx.net", "alias@", ""},
+		{"srs0 of other forwarder", "SRS0=abcd=TT=example.com=john@forwarder.net", "SRS1=", "SRS0=abcd=TT=example.com=john@forwarder.net"},
+		{"srs1 of other forwarder", "SRS1=wxyz=forwarder.net==abcd=TT=example.com=john@relay.net", "SRS1=", "SRS0=abcd=TT=example.com=john@forwarder.net"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := srs.Forward(tt.sender, now)
+			if err != nil {
+				t.Fatalf("Forward() error = %v", err)
+			}
+			if !strings.HasPrefix(addr, tt.prefix) || !strings.HasSuffix(addr, "@mailx.net") {
+				t.Fatalf("Forward() = %s", addr)
+			}
+			if tt.reverse == "" {
+				return
+			}
+
+			got, err := srs.Reverse(addr, now)
+			if err != nil {
+				t.Fatalf("Reverse(%s) error = %v", addr, err)
+			}
+			if got != tt.reverse {
+				t.Errorf("Reverse() = %s, want %s", got, tt.reverse)
+			}
+
+			got, err = srs.Reverse(strings.ToLower(addr), now)
+			if err != nil || !strings.EqualFold(got, tt.reverse) {
+				t.Errorf("Reverse() lowercase = %s, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestSRSReverseErrors(t *testing.T) {
+	srs := SRS{Secret: "secret", Domain: "mailx.net"}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	addr, err := srs.Forward("john@example.com", now)
+	if err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+
+	if _, err := srs.Reverse(addr, now.AddDate(0, 0, 30)); err != ErrSRSExpired {
+		t.Errorf("expected ErrSRSExpired, got %v", err)
+	}
+
+	other := SRS{Secret: "other", Domain: "mailx.net"}
+	if _, err := other.Reverse(addr, now); err != ErrSRSHash {
+		t.Errorf("expected ErrSRSHash, got %v", err)
+	}
+
+	for _, addr := range []string{"john@mailx.net", "SRS0=abc@mailx.net", "SRS0=abcd=TT=example.com=john@other.net"} {
+		if _, err := srs.Reverse(addr, now); err != ErrSRSAddress {
+			t.Errorf("Reverse(%s) expected ErrSRSAddress, got %v", addr, err)
+		}
+	}
+}
+
+func TestIsSRS(t *testing.T) {
+	if !IsSRS("SRS0=abcd=TT=example.com=john@mailx.net") || !IsSRS("srs1=x@mailx.net") {
+		t.Error("expected SRS address")
+	}
+	if IsSRS("john@mailx.net") {
+		t.Error("expected non-SRS address")
+	}
+}
@@ -28,6 +28,10 @@ export const userApi = {
     accessKeyList: () => api.get('/accesskeys'),
     accessKeyCreate: (data: any) => api.post('/accesskeys', data),
     accessKeyDelete: (id: string) => api.delete('/accesskeys/' + id),
+    audit: (data: any) => api.get('/user/audit', { params: data }),
+    sessionList: () => api.get('/user/sessions'),
+    sessionDelete: (id: string) => api.delete('/user/sessions/' + id),
+    sessionDeleteOthers: () => api.delete('/user/sessions'),
     clearSession: () => {
         localStorage.removeItem('email')
         window.location.href = '/'
@@ -9,6 +9,7 @@ export interface Alias {
   from_name: string
   catch_all: boolean
   local_part: string
+  spam_policy: string
 }
 
 export interface CustomDomain {